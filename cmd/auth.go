@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// authCmd represents the auth command
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "manage the cloudflare token",
+}
+
+// authCheckCmd checks the token of the selected zones
+var authCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "check the token can read and edit the DNS records",
+	Run: func(cmd *cobra.Command, args []string) {
+		var issues []utils.Issue
+		for _, zone := range Zones {
+			useZone(zone)
+			zoneIssues := checkAuth(true)
+			printIssues(zoneIssues)
+			issues = append(issues, zoneIssues...)
+		}
+		printSummary(issues, "Token cannot manage the zone")
+	},
+}
+
+// doctorCmd checks the config and the token
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "check the configuration and the cloudflare token",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("INFO - checking the configuration...")
+		issues := utils.ValidateConfig(Config, ConfigSources)
+		printIssues(issues)
+		for _, zone := range Zones {
+			useZone(zone)
+			fmt.Printf("INFO - checking the token of %s...\n", zone.DomainName)
+			zoneIssues := checkAuth(true)
+			printIssues(zoneIssues)
+			issues = append(issues, zoneIssues...)
+		}
+		printSummary(issues, "Some checks failed")
+	},
+}
+
+func init() {
+	authCheckCmd.Flags().BoolVar(&flagAllZones, "all-zones", false, "check every configured zone")
+	doctorCmd.Flags().BoolVar(&flagAllZones, "all-zones", false, "check every configured zone")
+
+	authCmd.AddCommand(authCheckCmd)
+}
+
+// checkAuth checks the token of the zone in use,
+// with edit the token must be allowed to change the DNS records
+func checkAuth(edit bool) []utils.Issue {
+	if CFToken == "" {
+		token, err := utils.ResolveToken(CurrentZone, utils.SecretsFile(Config))
+		if err != nil {
+			return []utils.Issue{{Level: utils.LevelError, Name: "token", Message: err.Error()}}
+		}
+		CFToken = token
+	}
+	issues := utils.VerifyToken(BaseAPI, CFToken)
+	if utils.HasErrors(issues) {
+		return issues
+	}
+	if ZoneID == "" {
+		id, err := utils.ResolveZoneID(BaseAPI, Domain, CFToken)
+		if err != nil {
+			return append(issues, utils.Issue{Level: utils.LevelError, Name: Domain, Message: err.Error()})
+		}
+		ZoneID = id
+	}
+	return append(issues, utils.CheckZoneAccess(BaseAPI, CFToken, ZoneID, Domain, edit)...)
+}
+
+// preflight stops the sync when the token cannot do it, a dry run only needs to read
+func preflight() {
+	issues := checkAuth(!flagDryRun)
+	printIssues(issues)
+	if utils.HasErrors(issues) {
+		fmt.Printf("ERROR - the token cannot sync %s, fix its permissions or use --skip-preflight\n", Domain)
+		os.Exit(1)
+	}
+}
+
+// printSummary prints the final PASS or FAIL line of the checks
+func printSummary(issues []utils.Issue, failure string) {
+	if utils.HasErrors(issues) {
+		fmt.Printf("FAIL\t%s\n", failure)
+		os.Exit(1)
+	}
+	if len(issues) > 0 {
+		fmt.Println("WARN - There is some checks with warning")
+	}
+	fmt.Println("PASS\tok")
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+	"github.com/mrinjamul/mrinjamulcf-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagOffline bool
+)
+
+// claimCmd asks for a new subdomain and adds it to the records file
+var claimCmd = &cobra.Command{
+	Use:   "claim",
+	Short: "claim a subdomain by adding it to the records file",
+	Long: `Claim a subdomain by adding it to the records file.
+
+Asks for the subdomain, the record type, the target and the owner, checks
+the subdomain is not taken in the records file or the zone and is not
+restricted, then adds the entry in the layout of fmt. Every answer can be
+given as a flag, e.g. in scripts together with --yes.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		records := readRecords(true)
+		restricted := loadRestricted(flagRestricted)
+
+		name := askClaimName(records, restricted)
+		recordType := askClaim("Record type", strings.ToUpper(flagRecordType), "CNAME", func(value string) error {
+			if !utils.TypeContains(EnabledRecordType, strings.ToUpper(value)) {
+				return fmt.Errorf("type %s is not synced, use one of %s", strings.ToUpper(value), strings.Join(EnabledRecordType, ", "))
+			}
+			return nil
+		})
+		recordType = strings.ToUpper(recordType)
+		content := askClaim("Target", flagRecordContent, "", func(value string) error {
+			return validTarget(recordType, value)
+		})
+		username := askClaim("Owner username", flagRecordOwner, "", func(value string) error {
+			if !utils.ValidUsername(value) {
+				return fmt.Errorf("owner username %q is invalid", value)
+			}
+			return nil
+		})
+		email := askClaim("Owner email", flagRecordEmail, "", func(value string) error {
+			if !utils.ValidEmail(value) {
+				return fmt.Errorf("owner email %q is invalid", value)
+			}
+			return nil
+		})
+		repo := askClaim("Repo url (optional)", flagRecordRepo, "", func(value string) error {
+			if value == "" {
+				return nil
+			}
+			if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+				return fmt.Errorf("%q is not a url", value)
+			}
+			return nil
+		})
+
+		entry := models.Records{
+			Description: flagRecordDescription,
+			Repo:        repo,
+			Owner:       models.Owner{Username: username, Email: email},
+			DNSOnly:     flagRecordDNSOnly,
+			Record: models.Record{
+				Type:    recordType,
+				Name:    name,
+				Content: content,
+			},
+		}
+		utils.ApplyTypeDefaults(&entry, TypeDefaults)
+		data, err := utils.MarshalRecords([]models.Records{entry})
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println("ERROR - fail to convert records")
+			os.Exit(1)
+		}
+		fmt.Println()
+		fmt.Print(string(data))
+		if ok := utils.ConfirmPrompt(fmt.Sprintf("Add %s to %s?", utils.FQDN(name, Domain), flagRecords)); !ok {
+			fmt.Println("INFO - records file is not changed")
+			return
+		}
+
+		updated := append(append([]models.Records(nil), records...), entry)
+		if saveRecords(records, updated) {
+			fmt.Printf("INFO - %s claimed for %s\n", utils.FQDN(name, Domain), username)
+			fmt.Printf("INFO - commit %s and open a pull request to get it synced\n", flagRecords)
+		}
+	},
+}
+
+func init() {
+	claimCmd.Flags().StringVar(&flagRecordName, "name", "", "subdomain to claim e.g. blog")
+	claimCmd.Flags().StringVar(&flagRecordType, "type", "", "type of the record e.g. A, CNAME")
+	claimCmd.Flags().StringVar(&flagRecordContent, "content", "", "target of the record e.g. username.github.io")
+	claimCmd.Flags().StringVar(&flagRecordOwner, "owner", "", "username of the owner")
+	claimCmd.Flags().StringVar(&flagRecordEmail, "email", "", "email of the owner")
+	claimCmd.Flags().StringVar(&flagRecordRepo, "repo", "", "url of the repo using the record")
+	claimCmd.Flags().StringVar(&flagRecordDescription, "description", "", "description of the record")
+	claimCmd.Flags().BoolVar(&flagRecordDNSOnly, "dns-only", false, "never proxy the record")
+	claimCmd.Flags().BoolVar(&flagOffline, "offline", false, "do not check the zone on cloudflare")
+	claimCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "print the diff of the records file without writing it")
+	claimCmd.Flags().StringVarP(&flagRecords, "file", "f", "", "specify the records file")
+	claimCmd.Flags().StringVarP(&flagRestricted, "restricted", "r", "", "specify the restricted subdomains file")
+	claimCmd.Flags().StringVar(&flagDomain, "domain", "", "specify the domain name")
+	bindConfigFlag(claimCmd, "file", "record_file")
+	bindConfigFlag(claimCmd, "restricted", "restricted_file")
+	bindConfigFlag(claimCmd, "domain", "domain_name")
+}
+
+// interactive checks if the answers can be asked again
+func interactive() bool {
+	return !utils.AssumeYes && !utils.AssumeNo && utils.IsTerminal(os.Stdin)
+}
+
+// askClaim asks until the answer is valid, the value given as a flag is
+// used without asking. It exits when the answer cannot be asked again.
+func askClaim(message string, value string, defaultValue string, validate func(string) error) string {
+	for {
+		if value == "" {
+			value = strings.TrimSpace(utils.Prompt(message, defaultValue))
+		}
+		err := validate(value)
+		if err == nil {
+			return value
+		}
+		fmt.Printf("ERROR - %v\n", err)
+		if !interactive() {
+			os.Exit(1)
+		}
+		value = ""
+	}
+}
+
+// askClaimName asks for a subdomain until it is available
+func askClaimName(records []models.Records, restricted *utils.RestrictedList) string {
+	var name string
+	askClaim("Subdomain", flagRecordName, "", func(value string) error {
+		canonical, err := utils.CanonicalName(value, Domain)
+		if err != nil {
+			return err
+		}
+		if canonical == "@" {
+			return fmt.Errorf("the root of %s cannot be claimed", Domain)
+		}
+		if err := claimAvailable(canonical, records, restricted); err != nil {
+			return err
+		}
+		name = canonical
+		return nil
+	})
+	return name
+}
+
+// claimAvailable checks the subdomain is not restricted, confusable or taken
+func claimAvailable(name string, records []models.Records, restricted *utils.RestrictedList) error {
+	fqdn := utils.FQDN(name, Domain)
+	if rule, ok := restricted.Match(name); ok {
+		return fmt.Errorf("%s is restricted (matched %s)", fqdn, rule)
+	}
+	// a single name is only checked against the restricted list
+	var warnings []string
+	for _, issue := range utils.CheckConfusables([]models.Records{{Record: models.Record{Name: name}}}, restricted, Domain) {
+		if issue.Level == utils.LevelError {
+			return fmt.Errorf("%s %s", fqdn, issue.Message)
+		}
+		warnings = append(warnings, fmt.Sprintf("%s %s", fqdn, issue.Message))
+	}
+	for _, entry := range records {
+		existing, err := utils.CanonicalName(entry.Record.Name, Domain)
+		if err != nil {
+			continue
+		}
+		if existing == name {
+			return fmt.Errorf("%s is already claimed by %s", fqdn, ownerName(entry.Owner))
+		}
+		if utils.Skeleton(existing) != utils.Skeleton(name) {
+			continue
+		}
+		if utils.ConfusableLevel(name, existing) == utils.LevelError {
+			return fmt.Errorf("%s is confusable with %s claimed by %s", fqdn, existing, ownerName(entry.Owner))
+		}
+		warnings = append(warnings, fmt.Sprintf("%s looks like %s claimed by %s", fqdn, existing, ownerName(entry.Owner)))
+	}
+	if flagOffline {
+		printWarnings(warnings)
+		return nil
+	}
+	connectZone()
+	resp, err := utils.CFFetch(BaseAPI, "zones/"+ZoneID+"/dns_records?"+url.Values{"name": {fqdn}}.Encode(), CFToken)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println("ERROR - fail to fetch records, use --offline to skip the check of the zone")
+		os.Exit(1)
+	}
+	if len(resp.Result) > 0 {
+		return fmt.Errorf("%s is already in use on the zone (%s %s)", fqdn, resp.Result[0].Type, resp.Result[0].Content)
+	}
+	printWarnings(warnings)
+	return nil
+}
+
+// printWarnings prints the warnings about an available name
+func printWarnings(warnings []string) {
+	for _, warning := range warnings {
+		fmt.Printf("WARN - %s\n", warning)
+	}
+}
+
+// validTarget checks the target suits the record type
+func validTarget(recordType string, target string) error {
+	if target == "" {
+		return fmt.Errorf("an answer is required")
+	}
+	switch recordType {
+	case "A":
+		if ip := net.ParseIP(target); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("%q is not an IPv4 address", target)
+		}
+	case "AAAA":
+		if ip := net.ParseIP(target); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("%q is not an IPv6 address", target)
+		}
+	case "CNAME":
+		if _, err := utils.NormalizeName(target); err != nil || !strings.Contains(target, ".") {
+			return fmt.Errorf("%q is not a host name", target)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+	"github.com/mrinjamul/mrinjamulcf-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagInitToken      string
+	flagInitZoneID     string
+	flagInitDomain     string
+	flagInitRecords    string
+	flagInitRestricted string
+	flagInitTypes      string
+	flagForce          bool
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "manage the configuration",
+}
+
+// configInitCmd generates the config file
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "generate the config file",
+	Run: func(cmd *cobra.Command, args []string) {
+		filename := configFile()
+		if _, err := os.Stat(filename); err == nil && !flagForce {
+			if ok := utils.ConfirmPrompt(fmt.Sprintf("%s already exists, do you want to overwrite it?", filename)); !ok {
+				fmt.Println("INFO - config file is not changed")
+				return
+			}
+		}
+
+		// ask for the values which are not given as flags
+		config := models.Config{
+			CFToken:        flagInitToken,
+			ZoneID:         flagInitZoneID,
+			DomainName:     flagInitDomain,
+			RecordFile:     flagInitRecords,
+			RestrictedFile: flagInitRestricted,
+		}
+		if flagInitToken == "" {
+			config.CFToken = utils.PromptSecret("Cloudflare API token")
+		}
+		if flagInitZoneID == "" {
+			config.ZoneID = utils.Prompt("Cloudflare zone id (empty to look it up from the domain)", "")
+		}
+		if flagInitDomain == "" {
+			config.DomainName = utils.Prompt("Domain name", Config.DomainName)
+		}
+		if flagInitRecords == "" {
+			config.RecordFile = utils.Prompt("Records file", Config.RecordFile)
+		}
+		if flagInitRestricted == "" {
+			config.RestrictedFile = utils.Prompt("Restricted subdomains file", Config.RestrictedFile)
+		}
+		types := flagInitTypes
+		if types == "" {
+			types = utils.Prompt("Record types to sync", strings.Join(Config.RecordType, ","))
+		}
+		if err := utils.SetConfigValue(&config, "record_type", types); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		err := utils.GenerateConfig(filename, config)
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println("ERROR - fail to write config file")
+			os.Exit(1)
+		}
+		fmt.Printf("INFO - config written to %s\n", filename)
+	},
+}
+
+// configShowCmd shows the configuration
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "show the configuration and where every value comes from",
+	Run: func(cmd *cobra.Command, args []string) {
+		if ConfigErr != nil {
+			fmt.Println(ConfigErr)
+			fmt.Println("ERROR - fail to parse config file")
+			os.Exit(1)
+		}
+		fmt.Println("# precedence: default < system < user < project < .env < env < flag")
+		for _, file := range ConfigFiles {
+			fmt.Printf("# %s\n", file)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 1, ' ', 0)
+		for _, key := range utils.ConfigKeys() {
+			fmt.Fprintf(w, "%s\t= %s\t(%s)\n", key, utils.ConfigValue(Config, key), ConfigSources[key])
+		}
+		w.Flush()
+	},
+}
+
+// configValidateCmd validates the configuration
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "check the configuration for errors",
+	Run: func(cmd *cobra.Command, args []string) {
+		if ConfigErr != nil {
+			fmt.Println(ConfigErr)
+			fmt.Printf("FAIL\t%s\n", "Config file cannot be parsed")
+			os.Exit(1)
+		}
+		issues := utils.ValidateConfig(Config, ConfigSources)
+		warn := printIssues(issues)
+		if utils.HasErrors(issues) {
+			fmt.Println("FAIL\tConfig is invalid")
+			os.Exit(1)
+		}
+		if warn {
+			fmt.Println("WARN - There is some settings with warning")
+		}
+		fmt.Println("PASS\tok")
+	},
+}
+
+// configSetCmd sets a value in the config file
+var configSetCmd = &cobra.Command{
+	Use:   "set key value",
+	Short: "set a value in the config file",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		key, value := args[0], args[1]
+		filename := configFile()
+		config, err := utils.ParseConfig(filename)
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Println(err)
+			fmt.Println("ERROR - fail to parse config file")
+			os.Exit(1)
+		}
+		err = utils.SetConfigValue(&config, key, value)
+		if err != nil {
+			fmt.Println(err)
+			fmt.Printf("INFO - available keys: %s\n", strings.Join(utils.ConfigKeys(), ", "))
+			os.Exit(1)
+		}
+		err = utils.WriteConfig(filename, config)
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println("ERROR - fail to write config file")
+			os.Exit(1)
+		}
+		fmt.Printf("INFO - %s = %s written to %s\n", key, utils.ConfigValue(config, key), filename)
+	},
+}
+
+// configSecretCmd stores a token in the encrypted secrets file
+var configSecretCmd = &cobra.Command{
+	Use:   "secret [zone]",
+	Short: "store the cloudflare token in the encrypted secrets file",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		zone := ""
+		if len(args) > 0 {
+			zone = args[0]
+		}
+		filename := Config.SecretsFile
+		if filename == "" {
+			filename = utils.SecretsPath()
+		}
+		passphrase, err := utils.Passphrase()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		secrets := make(map[string]string)
+		if _, err := os.Stat(filename); err == nil {
+			secrets, err = utils.ReadSecrets(filename, passphrase)
+			if err != nil {
+				fmt.Println(err)
+				fmt.Println("ERROR - fail to open the secrets file")
+				os.Exit(1)
+			}
+		}
+
+		// the token is read from stdin when it is piped
+		var token string
+		if utils.IsTerminal(os.Stdin) {
+			token = utils.PromptSecret("Cloudflare API token")
+		} else {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			token = strings.TrimSpace(string(data))
+		}
+		if token == "" {
+			fmt.Println("ERROR - token cannot be empty")
+			os.Exit(1)
+		}
+		secrets[utils.SecretKey(zone)] = token
+
+		err = utils.WriteSecrets(filename, secrets, passphrase)
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println("ERROR - fail to write the secrets file")
+			os.Exit(1)
+		}
+		fmt.Printf("INFO - %s = %s written to %s\n", utils.SecretKey(zone), utils.Redact(token), filename)
+	},
+}
+
+func init() {
+	configInitCmd.Flags().StringVar(&flagInitToken, "token", "", "cloudflare API token")
+	configInitCmd.Flags().StringVar(&flagInitZoneID, "zone-id", "", "cloudflare zone id")
+	configInitCmd.Flags().StringVar(&flagInitDomain, "domain", "", "domain name")
+	configInitCmd.Flags().StringVar(&flagInitRecords, "records", "", "records file")
+	configInitCmd.Flags().StringVar(&flagInitRestricted, "restricted", "", "restricted subdomains file")
+	configInitCmd.Flags().StringVar(&flagInitTypes, "types", "", "record types to sync e.g. A,CNAME")
+	configInitCmd.Flags().BoolVar(&flagForce, "force", false, "overwrite the existing config file")
+
+	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configSecretCmd)
+}
+
+// configFile returns the config file used by the config commands
+func configFile() string {
+	if flagConfig != "" {
+		return flagConfig
+	}
+	return utils.ConfigPath()
+}
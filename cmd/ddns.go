@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+	"github.com/mrinjamul/mrinjamulcf-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagIPv4      bool
+	flagIPv6      bool
+	flagIPv4URL   string
+	flagIPv6URL   string
+	flagInterface string
+	flagInterval  time.Duration
+)
+
+// ddnsCmd points A/AAAA records at the current address of the host
+var ddnsCmd = &cobra.Command{
+	Use:   "ddns name...",
+	Short: "update A/AAAA records with the current public address",
+	Long: `Update A/AAAA records with the current public address of the host.
+
+The address is asked to an endpoint which echoes the address of the caller
+(ipv4_url and ipv6_url in the config) or read from a network interface.
+The records are only updated when the address changed, the entries of the
+records file are updated too so sync does not revert them. With --interval
+the command keeps running and checks the address at every interval.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !flagIPv4 && !flagIPv6 {
+			flagIPv4 = true
+		}
+		if flagDryRun {
+			fmt.Println("INFO - dry run, nothing will be changed")
+		}
+		connectZone()
+
+		// last address applied for each record type
+		applied := make(map[string]string)
+		if flagInterval <= 0 {
+			if err := updateDDNS(args, applied); err != nil {
+				fmt.Println(err)
+				fmt.Println("ERROR - fail to update the addresses")
+				os.Exit(1)
+			}
+			return
+		}
+
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		ticker := time.NewTicker(flagInterval)
+		defer ticker.Stop()
+		fmt.Printf("INFO - checking the address every %s\n", flagInterval)
+		for {
+			if err := updateDDNS(args, applied); err != nil {
+				fmt.Println(err)
+				fmt.Printf("ERROR - fail to update the addresses, retrying in %s\n", flagInterval)
+			}
+			select {
+			case <-ticker.C:
+			case sig := <-stop:
+				fmt.Printf("INFO - %s received, stopping\n", sig)
+				return
+			}
+		}
+	},
+}
+
+func init() {
+	ddnsCmd.Flags().BoolVarP(&flagIPv4, "ipv4", "4", false, "update the A records (default when --ipv6 is not set)")
+	ddnsCmd.Flags().BoolVarP(&flagIPv6, "ipv6", "6", false, "update the AAAA records")
+	ddnsCmd.Flags().StringVar(&flagIPv4URL, "ipv4-url", "", "endpoint which echoes the public IPv4 address")
+	ddnsCmd.Flags().StringVar(&flagIPv6URL, "ipv6-url", "", "endpoint which echoes the public IPv6 address")
+	ddnsCmd.Flags().StringVarP(&flagInterface, "interface", "i", "", "read the address from the network interface instead e.g. eth0")
+	ddnsCmd.Flags().DurationVar(&flagInterval, "interval", 0, "keep running and check the address at every interval e.g. 5m")
+	ddnsCmd.Flags().StringVar(&flagPrivateIP, "private-ip", "", "how to report private addresses e.g. error, warn, off")
+	ddnsCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "show the changes without updating anything")
+	ddnsCmd.Flags().StringVarP(&flagRecords, "file", "f", "", "specify the records file")
+	ddnsCmd.Flags().StringVar(&flagDomain, "domain", "", "specify the domain name")
+	bindConfigFlag(ddnsCmd, "ipv4-url", "ipv4_url")
+	bindConfigFlag(ddnsCmd, "ipv6-url", "ipv6_url")
+	bindConfigFlag(ddnsCmd, "private-ip", "private_ip")
+	bindConfigFlag(ddnsCmd, "file", "record_file")
+	bindConfigFlag(ddnsCmd, "domain", "domain_name")
+}
+
+// detectIP returns the current address of the family
+func detectIP(v6 bool) (string, error) {
+	if flagInterface != "" {
+		return utils.InterfaceIP(flagInterface, v6)
+	}
+	endpoint := flagIPv4URL
+	if v6 {
+		endpoint = flagIPv6URL
+	}
+	return utils.PublicIP(endpoint, v6)
+}
+
+// updateDDNS points the records of the names at the current addresses,
+// applied keeps the addresses already applied so they are not checked again.
+// The names which cannot point at a private address are left unchanged.
+func updateDDNS(names []string, applied map[string]string) error {
+	var refused error
+	var families []bool
+	if flagIPv4 {
+		families = append(families, false)
+	}
+	if flagIPv6 {
+		families = append(families, true)
+	}
+	for _, v6 := range families {
+		recordType := utils.IPType(v6)
+		ip, err := detectIP(v6)
+		if err != nil {
+			return err
+		}
+		if applied[recordType] == ip {
+			continue
+		}
+		fmt.Printf("INFO - current %s address is %s\n", recordType, ip)
+		allowed, err := allowedNames(names, recordType, ip)
+		if err != nil {
+			refused = err
+		}
+		for _, name := range allowed {
+			if err := updateAddress(name, recordType, ip); err != nil {
+				return err
+			}
+		}
+		if err := updateRecordsFile(allowed, recordType, ip); err != nil {
+			return err
+		}
+		if !flagDryRun && len(allowed) == len(names) {
+			applied[recordType] = ip
+		}
+	}
+	return refused
+}
+
+// allowedNames returns the names which may point at the address, the
+// private addresses are checked like fmt does
+func allowedNames(names []string, recordType string, ip string) ([]string, error) {
+	var allowed []string
+	var refused []string
+	for _, name := range names {
+		canonical, err := utils.CanonicalName(name, Domain)
+		if err != nil {
+			return nil, err
+		}
+		issues := utils.CheckPrivateIPs([]models.Records{{Record: models.Record{Type: recordType, Name: canonical, Content: ip}}}, flagPrivateIP, PrivateIPAllow)
+		printIssues(issues)
+		if utils.HasErrors(issues) {
+			refused = append(refused, name)
+			continue
+		}
+		allowed = append(allowed, name)
+	}
+	if len(refused) > 0 {
+		return allowed, fmt.Errorf("%s not updated, use a public address or add them to `private_ip_allow`", strings.Join(refused, ", "))
+	}
+	return allowed, nil
+}
+
+// updateAddress updates the records of the name and type which do not point at the address
+func updateAddress(name string, recordType string, ip string) error {
+	canonical, err := utils.CanonicalName(name, Domain)
+	if err != nil {
+		return err
+	}
+	fqdn := utils.FQDN(canonical, Domain)
+	query := url.Values{"type": {recordType}, "name": {fqdn}}
+	resp, err := utils.CFFetch(BaseAPI, "zones/"+ZoneID+"/dns_records?"+query.Encode(), CFToken)
+	if err != nil {
+		return err
+	}
+	if len(resp.Result) == 0 {
+		fmt.Printf("WARN - %s has no %s record, add it to the records file and sync it first\n", fqdn, recordType)
+		return nil
+	}
+	for _, result := range resp.Result {
+		if result.Content == ip {
+			fmt.Printf("INFO - %s: %s is up to date\n", recordType, fqdn)
+			continue
+		}
+		record := models.Record{Type: recordType, Name: result.Name, Content: ip, Proxied: result.Proxied, TTL: result.TTL}
+		postBody, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if !flagDryRun {
+			if _, err := utils.CFPost("PUT", BaseAPI, "zones/"+ZoneID+"/dns_records/"+result.ID, postBody, CFToken); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("INFO - %s: %s %s -> %s\n", recordType, fqdn, result.Content, ip)
+	}
+	return nil
+}
+
+// updateRecordsFile points the entries of the names in the records file at the address
+func updateRecordsFile(names []string, recordType string, ip string) error {
+	records, err := utils.GetRecords(flagRecords)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var changed int
+	for _, name := range names {
+		for _, i := range findRecords(records, name, recordType) {
+			if records[i].Record.Content != ip {
+				records[i].Record.Content = ip
+				changed++
+			}
+		}
+	}
+	if changed == 0 || flagDryRun {
+		return nil
+	}
+	// the file is written like `record` and fmt would write it
+	for _, change := range canonicalRecords(records) {
+		fmt.Printf("INFO - %s\n", change)
+	}
+	data, err := encodeRecords(records)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(flagRecords, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("INFO - %d entry(s) of %s updated\n", changed, flagRecords)
+	return nil
+}
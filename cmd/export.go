@@ -10,49 +10,78 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// versionCmd represents the version command
+var (
+	flagExport string
+)
+
+// exportCmd represents the export command
 var exportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "export DNS records to file.",
 	Run: func(cmd *cobra.Command, args []string) {
-		var records []models.Records
-		var cfrecords []models.Record
-		// Set domain name if flag exists
-		if flagDomain != "" {
-			Domain = flagDomain
-		}
-		fmt.Println("INFO - export started...")
-		cfrecords = GetRecords(EnabledRecordType)
-		for _, record := range cfrecords {
-			var r models.Records
-			r.Record = record
-			records = append(records, r)
-		}
-		fmt.Println("INFO - exporting to file...")
-		err := ExportRecords(records)
-		if err != nil {
-			fmt.Println(err)
-			fmt.Println("ERROR - cannot able to export records")
-			fmt.Printf("FAIL\t%v\n", err)
+		if flagExport != "" && len(Zones) > 1 {
+			fmt.Println("ERROR - --file cannot be used with more than one zone")
 			os.Exit(1)
 		}
-		fmt.Println("INFO - export completed...")
+		for _, zone := range Zones {
+			useZone(zone)
+			exportZone()
+		}
 	},
 }
 
 func init() {
-	exportCmd.Flags().StringVarP(&flagRecords, "file", "f", "", "specify the export file")
+	exportCmd.Flags().StringVarP(&flagExport, "file", "f", "", "specify the export file")
 	exportCmd.Flags().StringVar(&flagDomain, "domain", "", "specify the domain name")
+	bindConfigFlag(exportCmd, "domain", "domain_name")
+	exportCmd.Flags().BoolVar(&flagAllZones, "all-zones", false, "export every configured zone")
+	exportCmd.Flags().StringArrayVarP(&flagFilter, "filter", "F", nil, filterHelp)
 }
 
+// exportZone exports the records of the zone in use
+func exportZone() {
+	var records []models.Records
+	var cfrecords []models.Record
+	fmt.Println("INFO - export started...")
+	connectZone()
+	cfrecords = GetRecords(EnabledRecordType)
+	filters := parseFilters()
+	// the owners of the records file are matched by the filters
+	owners := localOwners()
+	for _, record := range cfrecords {
+		var r models.Records
+		r.Record = record
+		if !filters.Match(models.Records{Owner: owners[record.Name], Record: record}, Domain) {
+			continue
+		}
+		records = append(records, r)
+	}
+	if len(filters) > 0 {
+		fmt.Printf("INFO - %d of %d records match the filter\n", len(records), len(cfrecords))
+	}
+	fmt.Println("INFO - exporting to file...")
+	err := ExportRecords(records)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println("ERROR - cannot able to export records")
+		fmt.Printf("FAIL\t%v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("INFO - export completed...")
+}
+
+// ExportRecords writes the records to the export file
 func ExportRecords(records []models.Records) error {
 	var configFile string
-	if flagRecords != "" {
-		configFile = flagRecords
+	if flagExport != "" {
+		configFile = flagExport
 	} else {
 		date := utils.NewDate()
 		num := utils.RandomNumber()
 		configFile = "dns_records_" + date + "_" + num + ".json"
+		if len(Zones) > 1 {
+			configFile = "dns_records_" + Domain + "_" + date + "_" + num + ".json"
+		}
 	}
 	data, err := json.Marshal(records)
 	if err != nil {
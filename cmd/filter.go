@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+	"github.com/mrinjamul/mrinjamulcf-cli/utils"
+)
+
+// flagFilter holds the filter expressions of list and export and sync --only
+var flagFilter []string
+
+// filterHelp is the usage of the filter flags
+const filterHelp = "select records e.g. name=blog*,owner=alice or content~github.io, repeat to match any"
+
+// parseFilters parses the filter flags
+func parseFilters() utils.Filters {
+	filters, err := utils.ParseFilters(flagFilter)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println("ERROR - invalid filter")
+		os.Exit(1)
+	}
+	return filters
+}
+
+// filterChanges keeps the changes of the records matching the filters,
+// remote-only records are matched on their remote fields
+func filterChanges(changes []utils.Change, filters utils.Filters) []utils.Change {
+	if len(filters) == 0 {
+		return changes
+	}
+	var selected []utils.Change
+	for _, change := range changes {
+		entry := change.Local
+		if change.Status == utils.StatusRemoteOnly {
+			entry = models.Records{Record: change.Remote}
+		}
+		if filters.Match(entry, Domain) {
+			selected = append(selected, change)
+		}
+	}
+	return selected
+}
@@ -1,9 +1,10 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/mrinjamul/mrinjamulcf-cli/models"
 	"github.com/mrinjamul/mrinjamulcf-cli/utils"
@@ -11,7 +12,19 @@ import (
 )
 
 var (
-	flagCheck bool
+	flagCheck     bool
+	flagVerify    bool
+	flagDiff      bool
+	flagPrivateIP string
+	flagSort      string
+	// PrivateIPAllow lists the names which may point at private addresses
+	PrivateIPAllow []string
+	// OwnerPolicy is the ownership rules for the records
+	OwnerPolicy models.OwnerPolicy
+	// GitHubAPI is the base url used to verify owners and repos
+	GitHubAPI string
+	// TypeDefaults is the proxy and TTL policy per record type
+	TypeDefaults map[string]models.TypeDefault
 )
 
 var fmtCmd = &cobra.Command{
@@ -19,18 +32,6 @@ var fmtCmd = &cobra.Command{
 	Short: "format the records",
 	Run: func(cmd *cobra.Command, args []string) {
 
-		if flagRecords == "" {
-			flagRecords = "records.json"
-		}
-		if flagRestricted == "" {
-			flagRestricted = "restricted.json"
-		}
-
-		// Set domain name if flag exists
-		if flagDomain != "" {
-			Domain = flagDomain
-		}
-
 		if flagCheck {
 			var warn bool
 			var hasError bool
@@ -46,7 +47,7 @@ var fmtCmd = &cobra.Command{
 			for id, record := range records {
 				fmt.Printf("INFO - id: %d\n", id+1)
 				fmt.Printf("INFO - %s: %s %s\n", record.Record.Type, record.Record.Name, record.Record.Content)
-				if !record.Record.Proxied && (record.Record.Type == "A" || record.Record.Type == "AAAA" || record.Record.Type == "CNAME") {
+				if !record.Record.Proxied && utils.WantsProxied(record, TypeDefaults) {
 					warn = true
 					fmt.Println("WARN - Proxied is false")
 					fmt.Println("WARN - Please check the record")
@@ -65,25 +66,77 @@ var fmtCmd = &cobra.Command{
 				}
 			}
 
+			// Normalize the names before validating them
+			nameIssues := utils.NormalizeRecords(records)
+			printIssues(nameIssues)
+			if utils.HasErrors(nameIssues) {
+				hasError = true
+				errorsList = append(errorsList, "Invalid names found")
+			}
+
 			// Check if the records includes restricted subdomains
-			var enabledRecordType []string = []string{"A", "AAAA", "CNAME", "TXT", "MX", "SRV"}
-			localRecords, err := utils.GetDNSRecords(flagRecords, enabledRecordType)
-			if err != nil {
-				fmt.Println(err)
-				fmt.Println("ERROR - cannot able to parse dns records")
-				fmt.Printf("FAIL\t%v\n", err)
-				os.Exit(1)
+			restricted := loadRestricted(flagRestricted)
+			var restrictedFound bool
+			for _, record := range records {
+				rule, ok := restricted.Match(utils.TrimDomain(record.Record.Name, Domain))
+				if !ok {
+					continue
+				}
+				if !restrictedFound {
+					restrictedFound = true
+					hasError = true
+					fmt.Println("ERROR - Restricted subdomains found")
+					fmt.Println("ERROR - Please check the record")
+					errorsList = append(errorsList, "Restricted subdomains found")
+					fmt.Println()
+				}
+				// print restricted records
+				fmt.Printf("ERROR - %s: %s %s (matched %s)\n", record.Record.Type, record.Record.Name, record.Record.Content, rule)
 			}
-			_, restrictedRecords := utils.RemoveRestrictedSubdomains(flagRestricted, localRecords)
-			if len(restrictedRecords) > 0 {
+
+			// Check if the names looks like restricted or existing names
+			confusableIssues := utils.CheckConfusables(records, restricted, Domain)
+			if printIssues(confusableIssues) {
+				warn = true
+			}
+			if utils.HasErrors(confusableIssues) {
 				hasError = true
-				fmt.Println("ERROR - Restricted subdomains found")
-				fmt.Println("ERROR - Please check the record")
-				errorsList = append(errorsList, "Restricted subdomains found")
-				fmt.Println()
-				// print restricted records
-				for _, record := range restrictedRecords {
-					fmt.Printf("ERROR - %s: %s %s\n", record.Type, record.Name, record.Content)
+				errorsList = append(errorsList, "Confusable names found")
+			}
+
+			// Check if the records points at private or reserved addresses
+			ipIssues := utils.CheckPrivateIPs(records, flagPrivateIP, PrivateIPAllow)
+			if printIssues(ipIssues) {
+				warn = true
+			}
+			if utils.HasErrors(ipIssues) {
+				hasError = true
+				fmt.Println("ERROR - Please use a public address or add the name to `private_ip_allow`")
+				errorsList = append(errorsList, "Private or reserved addresses found")
+			}
+
+			// Check the owners of the records
+			ownerIssues := utils.CheckOwners(records, OwnerPolicy, Domain)
+			if printIssues(ownerIssues) {
+				warn = true
+			}
+			if utils.HasErrors(ownerIssues) {
+				hasError = true
+				errorsList = append(errorsList, "Ownership rules violated")
+			}
+
+			// Verify the repos and owners against GitHub
+			if flagVerify {
+				fmt.Println("INFO - verifying owners and repos...")
+				utils.AddSecret(os.Getenv("GITHUB_TOKEN"))
+				host := utils.NewGitHubClient(GitHubAPI, os.Getenv("GITHUB_TOKEN"))
+				verifyIssues := utils.VerifyClaims(records, host, Domain)
+				if printIssues(verifyIssues) {
+					warn = true
+				}
+				if utils.HasErrors(verifyIssues) {
+					hasError = true
+					errorsList = append(errorsList, "Owner or repo verification failed")
 				}
 			}
 
@@ -106,59 +159,93 @@ var fmtCmd = &cobra.Command{
 			return
 		}
 
+		original, err := os.ReadFile(flagRecords)
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println("ERROR - fail to read local DNS records")
+			os.Exit(1)
+		}
 		records, err := utils.GetRecords(flagRecords)
 		if err != nil {
 			fmt.Println(err)
 			fmt.Println("ERROR - fail to parse local DNS records")
 			os.Exit(1)
 		}
-		restrictedList := utils.ReadRestrictedRecords(flagRestricted)
+		restricted := loadRestricted(flagRestricted)
 		var removeList []int
+		var removeRules []utils.RestrictedRule
+		dryRun := flagDryRun || flagDiff
 
 		var count uint
 		var removed bool
 		for i := range records {
-			var flag bool
-			records[i].Record.Proxiable = true
-			// Set Proxied to true if the record type is A, AAAA or CNAME
-			if (records[i].Record.Type == "A" || records[i].Record.Type == "AAAA" || records[i].Record.Type == "CNAME") && !records[i].Record.Proxied {
-				fmt.Println("INFO - Setting Proxied to true")
-				records[i].Record.Proxied = true
+			changes, err := canonicalRecord(&records[i])
+			if err != nil {
+				fmt.Println(err)
+				fmt.Println("ERROR - invalid name in local DNS records")
+				os.Exit(1)
+			}
+			for _, change := range changes {
+				fmt.Printf("INFO - %s\n", change)
+			}
+			if len(changes) > 0 {
 				count++
-				flag = true
-			}
-			// Set TTL to 1 if the record type is A, AAAA or CNAME
-			if (records[i].Record.Type == "A" || records[i].Record.Type == "AAAA" || records[i].Record.Type == "CNAME") && records[i].Record.TTL == 0 {
-				fmt.Println("INFO - Setting TTL to auto")
-				records[i].Record.TTL = 1
-				if !flag {
-					count++
-				}
-				flag = true
 			}
-			if utils.IsRestricted(records[i].Record.Name, restrictedList) {
+			if rule, ok := restricted.Match(records[i].Record.Name); ok {
 				// remove this record from the records
 				removeList = append(removeList, i)
+				removeRules = append(removeRules, rule)
 			}
 
 		}
 		// remove restricted records
 		if len(removeList) > 0 {
-			if ok := utils.ConfirmPrompt("Do you want to remove restricted subdomains?"); ok {
+			fmt.Println("INFO - restricted record(s) to be removed:")
+			for n, i := range removeList {
+				r := records[i]
+				fmt.Printf("\t%s: %s %s (owner: %s, matched %s)\n", r.Record.Type, r.Record.Name, r.Record.Content, r.Owner.Username, removeRules[n])
+			}
+			if dryRun && !utils.AssumeYes && (utils.AssumeNo || !utils.IsTerminal(os.Stdin)) {
+				// the prompt would answer no, the preview keeps them
+				fmt.Printf("INFO - %d restricted record(s) would be kept, the prompt answers no (use --yes to remove them)\n", len(removeList))
+			} else if dryRun {
+				fmt.Printf("INFO - %d restricted record(s) will be removed after confirmation\n", len(removeList))
 				count += uint(len(removeList))
 				removed = true
-				for _, i := range removeList {
-					records = removeRecords(records, i)
-				}
+				records = utils.RemoveRecords(records, removeList)
+			} else if ok := utils.ConfirmPrompt(fmt.Sprintf("Do you want to remove %d restricted subdomain(s)?", len(removeList))); ok {
+				count += uint(len(removeList))
+				removed = true
+				records = utils.RemoveRecords(records, removeList)
 			}
 		}
-		// write the records to the file
-		data, err := json.MarshalIndent(records, "", "\t")
+		// sort the records
+		err = utils.SortRecords(records, flagSort)
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println("ERROR - fail to sort records")
+			os.Exit(1)
+		}
+		data, err := utils.MarshalRecords(records)
 		if err != nil {
 			fmt.Println(err)
 			fmt.Println("ERROR - fail to convert records")
 			os.Exit(1)
 		}
+		if dryRun {
+			if flagDiff {
+				name := strings.TrimPrefix(filepath.ToSlash(flagRecords), "/")
+				fmt.Print(utils.UnifiedDiff("a/"+name, "b/"+name, original, data))
+			}
+			if string(data) == string(original) {
+				fmt.Println("INFO - records are already formatted")
+			} else {
+				fmt.Printf("INFO - %d record(s) would be formatted\n", count)
+			}
+			fmt.Println("INFO - dry run, records file is not changed")
+			return
+		}
+		// write the records to the file
 		err = os.WriteFile(flagRecords, data, 0644)
 		if err != nil {
 			fmt.Println(err)
@@ -175,13 +262,48 @@ var fmtCmd = &cobra.Command{
 
 func init() {
 	fmtCmd.Flags().BoolVarP(&flagCheck, "check", "c", false, "checks if the records has for errors")
+	fmtCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "report the changes without writing the file")
+	fmtCmd.Flags().BoolVar(&flagDiff, "diff", false, "print a unified diff of the changes without writing the file")
+	fmtCmd.Flags().BoolVar(&flagVerify, "verify", false, "verify owners and repos with --check")
 	fmtCmd.Flags().StringVarP(&flagRecords, "file", "f", "", "specify the records file")
 	fmtCmd.Flags().StringVarP(&flagRestricted, "restricted", "r", "", "specify the restricted domain")
 	fmtCmd.Flags().StringVar(&flagDomain, "domain", "", "specify the domain name")
+	fmtCmd.Flags().StringVar(&flagSort, "sort", "", "sort the records by name, type, owner or none")
+	fmtCmd.Flags().StringVar(&flagPrivateIP, "private-ip", "", "how to report private addresses e.g. error, warn, off")
+	bindConfigFlag(fmtCmd, "file", "record_file")
+	bindConfigFlag(fmtCmd, "restricted", "restricted_file")
+	bindConfigFlag(fmtCmd, "domain", "domain_name")
+	bindConfigFlag(fmtCmd, "sort", "sort_by")
+	bindConfigFlag(fmtCmd, "private-ip", "private_ip")
+}
+
+// printIssues prints the issues and reports if any of them is a warning
+func printIssues(issues []utils.Issue) bool {
+	var warn bool
+	for _, issue := range issues {
+		fmt.Println(issue)
+		if issue.Level == utils.LevelWarning {
+			warn = true
+		}
+	}
+	return warn
 }
 
-// removeRecords removes the records from the records file
-func removeRecords(records []models.Records, i int) []models.Records {
-	records[i] = records[len(records)-1]
-	return records[:len(records)-1]
+// canonicalRecord puts the entry in the layout of fmt: the name is normalized
+// e.g. lowercase, no trailing dot and no domain, and the proxy and TTL policy
+// of the record type is applied. It returns the changes made.
+func canonicalRecord(entry *models.Records) ([]string, error) {
+	name, err := utils.CanonicalName(entry.Record.Name, Domain)
+	if err != nil {
+		return nil, err
+	}
+	var changes []string
+	if name != entry.Record.Name {
+		changes = append(changes, fmt.Sprintf("Renaming %s to %s", entry.Record.Name, name))
+		entry.Record.Name = name
+	}
+	for _, change := range utils.ApplyTypeDefaults(entry, TypeDefaults) {
+		changes = append(changes, fmt.Sprintf("%s: %s", name, change))
+	}
+	return changes, nil
 }
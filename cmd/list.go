@@ -3,62 +3,287 @@ package main
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
 	"github.com/mrinjamul/mrinjamulcf-cli/utils"
 	"github.com/spf13/cobra"
 )
 
 var (
-	flagLocal bool
-	flagTypes string
+	flagLocal   bool
+	flagTypes   string
+	flagOutput  string
+	flagCompare bool
 )
 
+// listRow is a record printed by list, local and remote records are rendered the same way
+type listRow struct {
+	Status     string `json:"status,omitempty"`
+	Zone       string `json:"zone"`
+	Name       string `json:"name"`
+	FQDN       string `json:"fqdn"`
+	Type       string `json:"type"`
+	Content    string `json:"content"`
+	TTL        uint   `json:"ttl"`
+	Proxied    bool   `json:"proxied"`
+	Owner      string `json:"owner,omitempty"`
+	Email      string `json:"email,omitempty"`
+	ID         string `json:"id,omitempty"`
+	ModifiedOn string `json:"modified_on,omitempty"`
+	// Remote is the remote content of a record pending update
+	Remote string `json:"remote,omitempty"`
+}
+
 // listCmd represents the list command
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "list all records from remote/local",
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := validOutput(flagOutput); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if flagCompare && flagLocal {
+			fmt.Println("ERROR - --compare already lists the local records, do not use it with --local")
+			os.Exit(1)
+		}
 		// all type of dns records
 		types := []string{"A", "AAAA", "CNAME", "TXT", "MX", "SRV"}
 		if flagTypes != "" {
 			types = strings.Split(flagTypes, ",")
 		}
 
-		// list records from local json file
-		if flagLocal {
-			if flagRecords == "" {
-				flagRecords = "records.json"
+		filters := parseFilters()
+		var rows []listRow
+		for _, zone := range Zones {
+			useZone(zone)
+			if flagCompare {
+				rows = append(rows, compareZone(types)...)
+				continue
 			}
+			rows = append(rows, listZone(types)...)
+		}
+		printRows(filterRows(rows, filters))
+	},
+}
+
+func init() {
+	listCmd.Flags().StringVarP(&flagTypes, "type", "t", "", "specify the types of records")
+	listCmd.Flags().BoolVarP(&flagLocal, "local", "l", false, "specify the target to list e.g. local")
+	listCmd.Flags().BoolVar(&flagAllZones, "all-zones", false, "list every configured zone")
+	listCmd.Flags().StringVarP(&flagOutput, "output", "o", OutputTable, "output format e.g. table, wide, json, yaml, csv")
+	listCmd.Flags().BoolVarP(&flagCompare, "compare", "c", false, "merge the local and remote records and show the sync status")
+	listCmd.Flags().StringArrayVarP(&flagFilter, "filter", "F", nil, filterHelp)
+}
+
+// listZone lists the records of the zone in use
+func listZone(types []string) []listRow {
+	info := humanOutput(flagOutput)
+	var rows []listRow
+	// list records from local json file
+	if flagLocal {
+		if info {
 			fmt.Println("INFO - gathering DNS Records from local ...")
-			localRecords, err := utils.GetDNSRecords(flagRecords, EnabledRecordType)
-			if err != nil {
-				fmt.Println(err)
-				fmt.Println("ERROR - fail to parse local DNS records")
-				os.Exit(1)
+		}
+		entries, err := utils.GetRecords(flagRecords)
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println("ERROR - fail to parse local DNS records")
+			os.Exit(1)
+		}
+		for _, entry := range entries {
+			if !utils.TypeContains(EnabledRecordType, entry.Record.Type) || !utils.TypeContains(types, entry.Record.Type) {
+				continue
+			}
+			rows = append(rows, localRow(entry))
+		}
+		if info {
+			fmt.Printf("INFO - got %d local DNS Records of %s\n", len(rows), Domain)
+		}
+		return rows
+	}
+
+	connectZone()
+	// gather from remote
+	if info {
+		fmt.Println("INFO - gathering DNS Records from cloudflare api...")
+	}
+	owners := localOwners()
+	for _, result := range GetResults(types) {
+		row := listRow{
+			Zone:       Domain,
+			Name:       utils.TrimDomain(result.Name, Domain),
+			FQDN:       result.Name,
+			Type:       result.Type,
+			Content:    result.Content,
+			TTL:        result.TTL,
+			Proxied:    result.Proxied,
+			ID:         result.ID,
+			ModifiedOn: result.ModifiedOn,
+		}
+		if owner, ok := owners[result.Name]; ok {
+			row.Owner, row.Email = owner.Username, owner.Email
+		}
+		rows = append(rows, row)
+	}
+	if info {
+		fmt.Printf("INFO - got %d registered DNS Records on cf \n", len(rows))
+	}
+	return rows
+}
+
+// compareZone merges the records file with the zone in use and shows what sync would do
+func compareZone(types []string) []listRow {
+	info := humanOutput(flagOutput)
+	connectZone()
+	// only the types sync manages are compared
+	var synced []string
+	for _, t := range EnabledRecordType {
+		if utils.TypeContains(types, t) {
+			synced = append(synced, t)
+		}
+	}
+	if info {
+		fmt.Println("INFO - comparing DNS Records of the repository and cloudflare api...")
+	}
+	results := GetResults(synced)
+	modified := make(map[string]string)
+	for _, result := range results {
+		modified[result.ID] = result.ModifiedOn
+	}
+	var entries []models.Records
+	for _, entry := range localEntries() {
+		if utils.TypeContains(synced, entry.Record.Type) {
+			entries = append(entries, entry)
+		}
+	}
+	changes := utils.PlanChanges(entries, utils.Concat(nil, results), loadRestricted(flagRestricted), Domain)
+
+	var rows []listRow
+	for _, change := range changes {
+		row := listRow{
+			Status:     change.Status,
+			Zone:       Domain,
+			Name:       utils.TrimDomain(change.Name(), Domain),
+			FQDN:       change.Name(),
+			ID:         change.Remote.ID,
+			ModifiedOn: modified[change.Remote.ID],
+		}
+		record := change.Local.Record
+		if change.Status == utils.StatusRemoteOnly {
+			record = change.Remote
+		}
+		row.Type, row.Content, row.TTL, row.Proxied = record.Type, record.Content, record.TTL, record.Proxied
+		row.Owner, row.Email = change.Local.Owner.Username, change.Local.Owner.Email
+		if change.Status == utils.StatusUpdate {
+			row.Remote = change.Remote.Content
+			if change.Remote.Proxied != record.Proxied {
+				row.Remote = fmt.Sprintf("%s (proxied %t)", change.Remote.Content, change.Remote.Proxied)
 			}
-			for _, record := range localRecords {
-				fmt.Printf("%s: %s.%s -> %s\t%d\n", record.Type, record.Name, Domain, record.Content, record.TTL)
+			if !utils.SameTTL(change.Remote.TTL, record.TTL) {
+				row.Remote = fmt.Sprintf("%s (ttl %d)", row.Remote, change.Remote.TTL)
 			}
-			fmt.Printf("INFO - got %d registered DNS Records on cf \n", len(localRecords))
-			return
 		}
+		rows = append(rows, row)
+	}
+	if info {
+		var counts []string
+		for _, status := range []string{utils.StatusInSync, utils.StatusCreate, utils.StatusUpdate, utils.StatusRemoteOnly, utils.StatusRestricted} {
+			counts = append(counts, fmt.Sprintf("%d %s", len(utils.ChangesWith(changes, status)), status))
+		}
+		fmt.Printf("INFO - %s\n", strings.Join(counts, ", "))
+	}
+	return rows
+}
 
-		// Set domain name if flag exists
-		if flagDomain != "" {
-			Domain = flagDomain
+// localRow renders the entry of the records file like sync would push it
+func localRow(entry models.Records) listRow {
+	utils.ApplyTypeDefaults(&entry, TypeDefaults)
+	name, err := utils.CanonicalName(entry.Record.Name, Domain)
+	if err != nil {
+		name = entry.Record.Name
+	}
+	return listRow{
+		Zone:    Domain,
+		Name:    name,
+		FQDN:    utils.FQDN(name, Domain),
+		Type:    entry.Record.Type,
+		Content: entry.Record.Content,
+		TTL:     entry.Record.TTL,
+		Proxied: entry.Record.Proxied,
+		Owner:   entry.Owner.Username,
+		Email:   entry.Owner.Email,
+	}
+}
+
+// localOwners maps the names of the records file to their owner,
+// a records file which cannot be read has no owners
+func localOwners() map[string]models.Owner {
+	owners := make(map[string]models.Owner)
+	entries, err := utils.GetRecords(flagRecords)
+	if err != nil {
+		return owners
+	}
+	for _, entry := range entries {
+		if name, err := utils.CanonicalName(entry.Record.Name, Domain); err == nil {
+			owners[utils.FQDN(name, Domain)] = entry.Owner
 		}
-		// gather from remote
-		fmt.Println("INFO - gathering DNS Records from cloudflare api...")
-		allRecords := GetRecords(types)
-		for _, record := range allRecords {
-			fmt.Printf("%s: %s.%s -> %s\t%d\n", record.Type, record.Name, Domain, record.Content, record.TTL)
+	}
+	return owners
+}
+
+// entry returns the row as an entry of the records file
+func (r listRow) entry() models.Records {
+	return models.Records{
+		Owner:  models.Owner{Username: r.Owner, Email: r.Email},
+		Record: models.Record{Name: r.Name, Type: r.Type, Content: r.Content, Proxied: r.Proxied, TTL: r.TTL},
+	}
+}
+
+// filterRows keeps the rows matching the filters
+func filterRows(rows []listRow, filters utils.Filters) []listRow {
+	if len(filters) == 0 {
+		return rows
+	}
+	var selected []listRow
+	for _, row := range rows {
+		if filters.Match(row.entry(), row.Zone) {
+			selected = append(selected, row)
 		}
-		fmt.Printf("INFO - got %d registered DNS Records on cf \n", len(allRecords))
-	},
+	}
+	return selected
 }
 
-func init() {
-	listCmd.Flags().StringVarP(&flagTypes, "type", "t", "", "specify the types of records")
-	listCmd.Flags().BoolVarP(&flagLocal, "local", "l", false, "specify the target to list e.g. local")
+// printRows prints the rows in the format of --output
+func printRows(rows []listRow) {
+	var columns []column
+	if flagCompare {
+		columns = append(columns, column{Header: "STATUS", Value: func(i int) string { return rows[i].Status }})
+	}
+	columns = append(columns, []column{
+		{Header: "ZONE", Wide: true, Value: func(i int) string { return rows[i].Zone }},
+		{Header: "NAME", Value: func(i int) string { return rows[i].Name }},
+		{Header: "FQDN", Wide: true, Value: func(i int) string { return rows[i].FQDN }},
+		{Header: "TYPE", Value: func(i int) string { return rows[i].Type }},
+		{Header: "CONTENT", Value: func(i int) string { return rows[i].Content }},
+		{Header: "TTL", Value: func(i int) string { return strconv.FormatUint(uint64(rows[i].TTL), 10) }},
+		{Header: "PROXIED", Value: func(i int) string { return strconv.FormatBool(rows[i].Proxied) }},
+		{Header: "OWNER", Value: func(i int) string { return rows[i].Owner }},
+		{Header: "EMAIL", Wide: true, Value: func(i int) string { return rows[i].Email }},
+		{Header: "ID", Wide: true, Value: func(i int) string { return rows[i].ID }},
+		{Header: "MODIFIED", Wide: true, Value: func(i int) string { return rows[i].ModifiedOn }},
+	}...)
+	if flagCompare {
+		columns = append(columns, column{Header: "REMOTE", Wide: true, Value: func(i int) string { return rows[i].Remote }})
+	}
+	if rows == nil {
+		rows = []listRow{}
+	}
+	if err := writeRows(os.Stdout, flagOutput, columns, len(rows), rows); err != nil {
+		fmt.Println(err)
+		fmt.Println("ERROR - fail to print the records")
+		os.Exit(1)
+	}
 }
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
 	"github.com/mrinjamul/mrinjamulcf-cli/utils"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
@@ -14,11 +16,58 @@ var (
 	flagRestricted string
 	ZoneID         string
 	CFToken        string
+	// Config is the loaded configuration
+	Config models.Config
+	// ConfigSources tells where every config value came from
+	ConfigSources utils.ConfigSources
+	// ConfigFiles are the loaded config files
+	ConfigFiles []string
+	// ConfigErr is the error while loading the configuration
+	ConfigErr error
 )
 
+// configKeyAnnotation marks the flags which overrides a config key
+const configKeyAnnotation = "config_key"
+
 func init() {
 }
 
+// bindConfigFlag marks the flag of the command as an override of the config key
+func bindConfigFlag(cmd *cobra.Command, name string, key string) {
+	cmd.Flags().SetAnnotation(name, configKeyAnnotation, []string{key})
+}
+
+// loadConfig loads the configuration, the flags given to the command overrides it
+func loadConfig(cmd *cobra.Command) error {
+	loaded, err := utils.LoadConfig(flagConfig)
+	config, sources := loaded.Config, loaded.Sources
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		key, ok := flag.Annotations[configKeyAnnotation]
+		if !ok || err != nil {
+			return
+		}
+		err = utils.SetConfigValue(&config, key[0], flag.Value.String())
+		sources[key[0]] = utils.SourceFlag
+	})
+	Config, ConfigSources, ConfigFiles = config, sources, loaded.Files
+	utils.AddSecret(config.CFToken)
+	for _, zone := range config.Zones {
+		utils.AddSecret(zone.CFToken)
+	}
+
+	Domain, flagDomain = config.DomainName, config.DomainName
+	flagRecords, flagRestricted = config.RecordFile, config.RestrictedFile
+	CFToken, ZoneID, EnabledRecordType = config.CFToken, config.ZoneID, config.RecordType
+	flagPrivateIP, PrivateIPAllow = config.PrivateIP, config.PrivateIPAllow
+	OwnerPolicy = config.Owners
+	GitHubAPI = config.GitHubAPI
+	BaseAPI = utils.APIBase(config.APIURL)
+	flagIPv4URL, flagIPv6URL = config.IPv4URL, config.IPv6URL
+	flagSort = config.SortBy
+	TypeDefaults = config.TypeDefaults
+	return err
+}
+
 func main() {
 	var rootCmd = &cobra.Command{
 		Use:   "mrinjamul",
@@ -35,43 +84,49 @@ func main() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(fmtCmd)
 	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(reviewCmd)
+	rootCmd.AddCommand(recordCmd)
+	rootCmd.AddCommand(claimCmd)
+	rootCmd.AddCommand(ddnsCmd)
+	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(exportCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(authCmd)
+	rootCmd.AddCommand(doctorCmd)
 	// add flags
-	// rootCmd.Flags().StringVarP(&flagConfig, "config", "c", "", "config file")
+	rootCmd.PersistentFlags().StringVar(&flagConfig, "config", "", "config file")
+	rootCmd.PersistentFlags().StringVar(&flagProfile, "profile", "", "name of the zone profile to use")
+	rootCmd.PersistentFlags().StringVar(&flagZone, "zone", "", "domain name or id of the zone to use")
+	rootCmd.PersistentFlags().BoolVarP(&utils.AssumeYes, "yes", "y", false, "answer yes to every prompt")
+	rootCmd.PersistentFlags().BoolVar(&utils.AssumeNo, "no", false, "answer no to every prompt")
 
 	// PreRun
-	_, present := os.LookupEnv("CONFIG_FILE")
-	if present {
-		flagConfig = os.Getenv("CONFIG_FILE")
-	}
-	// get config variables
-	flagDomain, flagRecords, flagRestricted, CFToken, ZoneID, EnabledRecordType = utils.GetConfig(flagConfig)
-
-	// get records file
-	_, present = os.LookupEnv("RECORD_FILE")
-	if present {
-		flagRecords = os.Getenv("RECORD_FILE")
-	}
-	// get restricted file
-	_, present = os.LookupEnv("RESTRICTED_FILE")
-	if present {
-		flagRestricted = os.Getenv("RESTRICTED_FILE")
-	}
-	// Get domain Name
-	_, present = os.LookupEnv("DOMAIN_NAME")
-	if present {
-		flagDomain = os.Getenv("DOMAIN_NAME")
-	}
-	// get CF TOKEN
-	_, present = os.LookupEnv("CF_TOK")
-	if present {
-		CFToken = os.Getenv("CF_TOK")
-	}
-	// get CF ZONE ID
-	_, present = os.LookupEnv("CF_ZID")
-	if present {
-		ZoneID = os.Getenv("CF_ZID")
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if utils.AssumeYes && utils.AssumeNo {
+			fmt.Println("ERROR - --yes and --no cannot be used together")
+			os.Exit(1)
+		}
+		if flagConfig == "" {
+			flagConfig = os.Getenv("CONFIG_FILE")
+		}
+		// get config variables
+		ConfigErr = loadConfig(cmd)
+		if ConfigErr != nil && cmd.Parent() != configCmd {
+			fmt.Println(ConfigErr)
+			fmt.Println("ERROR - fail to parse config file")
+			fmt.Println("run `mrinjamulcf-cli config validate` to check the config file")
+			os.Exit(1)
+		}
+		if ConfigErr == nil {
+			ConfigErr = resolveZones()
+		}
+		if ConfigErr != nil && cmd.Parent() != configCmd {
+			fmt.Println(ConfigErr)
+			fmt.Println("ERROR - fail to select the zone")
+			os.Exit(1)
+		}
 	}
 
 	err := rootCmd.Execute()
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/utils"
+)
+
+// Output formats of the listings
+const (
+	OutputTable = "table"
+	OutputWide  = "wide"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputCSV   = "csv"
+)
+
+// OutputFormats are the formats accepted by --output
+var OutputFormats = []string{OutputTable, OutputWide, OutputJSON, OutputYAML, OutputCSV}
+
+// column is a column of the table, wide and csv outputs
+type column struct {
+	Header string
+	// Wide columns are left out of the table output
+	Wide bool
+	// Value returns the cell of the i-th row
+	Value func(i int) string
+}
+
+// validOutput checks the output format
+func validOutput(format string) error {
+	if !utils.TypeContains(OutputFormats, format) {
+		return fmt.Errorf("unknown output format %q (use %s)", format, strings.Join(OutputFormats, ", "))
+	}
+	return nil
+}
+
+// humanOutput checks if the output is read by people, the INFO lines are left out of the others
+func humanOutput(format string) bool {
+	return format == OutputTable || format == OutputWide
+}
+
+// writeRows writes the rows in the format, json and yaml encode data
+// which holds the same rows
+func writeRows(w io.Writer, format string, columns []column, count int, data interface{}) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(data)
+	case OutputYAML:
+		out, err := utils.MarshalYAML(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	case OutputCSV:
+		cw := csv.NewWriter(w)
+		var header []string
+		for _, c := range columns {
+			header = append(header, strings.ToLower(strings.ReplaceAll(c.Header, " ", "_")))
+		}
+		cw.Write(header)
+		for i := 0; i < count; i++ {
+			var record []string
+			for _, c := range columns {
+				record = append(record, c.Value(i))
+			}
+			cw.Write(record)
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	var header []string
+	for _, c := range columns {
+		if c.Wide && format != OutputWide {
+			continue
+		}
+		header = append(header, c.Header)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for i := 0; i < count; i++ {
+		var cells []string
+		for _, c := range columns {
+			if c.Wide && format != OutputWide {
+				continue
+			}
+			cells = append(cells, c.Value(i))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+	"github.com/mrinjamul/mrinjamulcf-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagRecordName        string
+	flagRecordType        string
+	flagRecordContent     string
+	flagRecordOwner       string
+	flagRecordEmail       string
+	flagRecordRepo        string
+	flagRecordDescription string
+	flagRecordDNSOnly     bool
+	flagRecordTTL         uint
+	flagRecordOutput      string
+)
+
+// recordCmd manages the entries of the records file
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "add, edit, remove and show the entries of the records file",
+}
+
+// recordAddCmd adds an entry to the records file
+var recordAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "add an entry to the records file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		records := readRecords(true)
+		name := canonicalRecordName(flagRecordName)
+		recordType := strings.ToUpper(flagRecordType)
+		if recordType == "" || flagRecordContent == "" {
+			fmt.Println("ERROR - --type and --content are required")
+			os.Exit(1)
+		}
+		if !utils.TypeContains(utils.KnownRecordTypes, recordType) {
+			fmt.Printf("ERROR - unknown record type %s, use one of %s\n", recordType, strings.Join(utils.KnownRecordTypes, ", "))
+			os.Exit(1)
+		}
+		if err := validTarget(recordType, flagRecordContent); err != nil {
+			fmt.Printf("ERROR - %s\n", err)
+			os.Exit(1)
+		}
+		for _, entry := range records {
+			existing, err := utils.CanonicalName(entry.Record.Name, Domain)
+			if err != nil || existing != name {
+				continue
+			}
+			if strings.EqualFold(entry.Record.Type, recordType) {
+				fmt.Printf("ERROR - %s %s already exists, use `record edit` to change it\n", recordType, name)
+				os.Exit(1)
+			}
+			if recordType == "CNAME" || strings.EqualFold(entry.Record.Type, "CNAME") {
+				fmt.Printf("ERROR - %s already has a %s record, a CNAME cannot share its name\n", name, entry.Record.Type)
+				os.Exit(1)
+			}
+		}
+
+		entry := models.Records{
+			Description: flagRecordDescription,
+			Repo:        flagRecordRepo,
+			Owner:       models.Owner{Username: flagRecordOwner, Email: flagRecordEmail},
+			DNSOnly:     flagRecordDNSOnly,
+			Record: models.Record{
+				Type:    recordType,
+				Name:    name,
+				Content: flagRecordContent,
+				TTL:     flagRecordTTL,
+			},
+		}
+		utils.ApplyTypeDefaults(&entry, TypeDefaults)
+		updated := append(append([]models.Records(nil), records...), entry)
+		if saveRecords(records, updated) {
+			fmt.Printf("INFO - %s: %s %s added\n", entry.Record.Type, entry.Record.Name, entry.Record.Content)
+		}
+	},
+}
+
+// recordEditCmd changes an entry of the records file
+var recordEditCmd = &cobra.Command{
+	Use:   "edit name",
+	Short: "change an entry of the records file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		records := readRecords(false)
+		i := findRecord(records, args[0], flagRecordType)
+		updated := append([]models.Records(nil), records...)
+		entry := &updated[i]
+		flags := cmd.Flags()
+		if flags.Changed("content") {
+			if err := validTarget(strings.ToUpper(entry.Record.Type), flagRecordContent); err != nil {
+				fmt.Printf("ERROR - %s\n", err)
+				os.Exit(1)
+			}
+			entry.Record.Content = flagRecordContent
+		}
+		if flags.Changed("ttl") {
+			entry.Record.TTL = flagRecordTTL
+		}
+		if flags.Changed("dns-only") {
+			entry.DNSOnly = flagRecordDNSOnly
+			// let the policy decide the proxied state again
+			entry.Record.Proxied = false
+		}
+		if flags.Changed("owner") {
+			entry.Owner.Username = flagRecordOwner
+		}
+		if flags.Changed("email") {
+			entry.Owner.Email = flagRecordEmail
+		}
+		if flags.Changed("repo") {
+			entry.Repo = flagRecordRepo
+		}
+		if flags.Changed("description") {
+			entry.Description = flagRecordDescription
+		}
+		utils.ApplyTypeDefaults(entry, TypeDefaults)
+		if entry.Record == records[i].Record && entry.Owner == records[i].Owner && entry.Repo == records[i].Repo &&
+			entry.Description == records[i].Description && entry.DNSOnly == records[i].DNSOnly {
+			fmt.Printf("INFO - %s: %s is not changed\n", entry.Record.Type, entry.Record.Name)
+			return
+		}
+		if saveRecords(records, updated) {
+			fmt.Printf("INFO - %s: %s %s updated\n", entry.Record.Type, entry.Record.Name, entry.Record.Content)
+		}
+	},
+}
+
+// recordRmCmd removes an entry from the records file
+var recordRmCmd = &cobra.Command{
+	Use:     "rm name",
+	Aliases: []string{"remove"},
+	Short:   "remove an entry from the records file",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		records := readRecords(false)
+		i := findRecord(records, args[0], flagRecordType)
+		entry := records[i]
+		if saveRecords(records, utils.RemoveRecords(records, []int{i})) {
+			fmt.Printf("INFO - %s: %s %s removed\n", entry.Record.Type, entry.Record.Name, entry.Record.Content)
+		}
+	},
+}
+
+// recordShowCmd shows the entries of a name
+var recordShowCmd = &cobra.Command{
+	Use:   "show name",
+	Short: "show the entries of a name in the records file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		records := readRecords(false)
+		matches := findRecords(records, args[0], flagRecordType)
+		if len(matches) == 0 {
+			fmt.Printf("ERROR - %s not found in %s\n", args[0], flagRecords)
+			os.Exit(1)
+		}
+		var entries []models.Records
+		for _, i := range matches {
+			entries = append(entries, records[i])
+		}
+		var data []byte
+		var err error
+		switch flagRecordOutput {
+		case OutputJSON:
+			data, err = utils.MarshalRecords(entries)
+		case OutputYAML:
+			data, err = utils.MarshalYAML(entries)
+		default:
+			err = fmt.Errorf("unknown output %q (use json or yaml)", flagRecordOutput)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Print(string(data))
+	},
+}
+
+func init() {
+	recordAddCmd.Flags().StringVar(&flagRecordName, "name", "", "name of the record relative to the domain e.g. blog")
+	recordAddCmd.Flags().StringVar(&flagRecordType, "type", "", "type of the record e.g. A, CNAME")
+	recordAddCmd.MarkFlagRequired("name")
+	for _, cmd := range []*cobra.Command{recordAddCmd, recordEditCmd} {
+		cmd.Flags().StringVar(&flagRecordContent, "content", "", "content of the record e.g. username.github.io")
+		cmd.Flags().StringVar(&flagRecordOwner, "owner", "", "username of the owner")
+		cmd.Flags().StringVar(&flagRecordEmail, "email", "", "email of the owner")
+		cmd.Flags().StringVar(&flagRecordRepo, "repo", "", "url of the repo using the record")
+		cmd.Flags().StringVar(&flagRecordDescription, "description", "", "description of the record")
+		cmd.Flags().BoolVar(&flagRecordDNSOnly, "dns-only", false, "never proxy the record")
+		cmd.Flags().UintVar(&flagRecordTTL, "ttl", 0, "TTL of the record, 1 is automatic (default from the type policy)")
+		cmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "print the diff of the records file without writing it")
+	}
+	for _, cmd := range []*cobra.Command{recordEditCmd, recordRmCmd, recordShowCmd} {
+		cmd.Flags().StringVar(&flagRecordType, "type", "", "type of the record when the name has several")
+	}
+	recordRmCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "print the diff of the records file without writing it")
+	recordShowCmd.Flags().StringVarP(&flagRecordOutput, "output", "o", OutputJSON, "output format e.g. json, yaml")
+
+	for _, cmd := range []*cobra.Command{recordAddCmd, recordEditCmd, recordRmCmd, recordShowCmd} {
+		cmd.Flags().StringVarP(&flagRecords, "file", "f", "", "specify the records file")
+		cmd.Flags().StringVar(&flagDomain, "domain", "", "specify the domain name")
+		bindConfigFlag(cmd, "file", "record_file")
+		bindConfigFlag(cmd, "domain", "domain_name")
+		recordCmd.AddCommand(cmd)
+	}
+	for _, cmd := range []*cobra.Command{recordAddCmd, recordEditCmd, recordRmCmd} {
+		cmd.Flags().StringVarP(&flagRestricted, "restricted", "r", "", "specify the restricted subdomains file")
+		bindConfigFlag(cmd, "restricted", "restricted_file")
+	}
+}
+
+// readRecords reads the records file, a missing file is empty when create is set
+func readRecords(create bool) []models.Records {
+	records, err := utils.GetRecords(flagRecords)
+	if err != nil {
+		if create && os.IsNotExist(err) {
+			return []models.Records{}
+		}
+		fmt.Println(err)
+		fmt.Println("ERROR - fail to parse local DNS records")
+		os.Exit(1)
+	}
+	return records
+}
+
+// canonicalRecordName returns the canonical name or exits when it is invalid
+func canonicalRecordName(name string) string {
+	canonical, err := utils.CanonicalName(name, Domain)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Printf("ERROR - invalid name %q\n", name)
+		os.Exit(1)
+	}
+	return canonical
+}
+
+// findRecords returns the indexes of the entries of the name and type, any type when it is empty
+func findRecords(records []models.Records, name string, recordType string) []int {
+	name = canonicalRecordName(name)
+	var matches []int
+	for i, entry := range records {
+		existing, err := utils.CanonicalName(entry.Record.Name, Domain)
+		if err != nil || existing != name {
+			continue
+		}
+		if recordType != "" && !strings.EqualFold(entry.Record.Type, recordType) {
+			continue
+		}
+		matches = append(matches, i)
+	}
+	return matches
+}
+
+// findRecord returns the index of the single entry of the name and type
+func findRecord(records []models.Records, name string, recordType string) int {
+	matches := findRecords(records, name, recordType)
+	switch len(matches) {
+	case 0:
+		fmt.Printf("ERROR - %s not found in %s\n", strings.TrimSpace(name+" "+strings.ToUpper(recordType)), flagRecords)
+		os.Exit(1)
+	case 1:
+		return matches[0]
+	}
+	var types []string
+	for _, i := range matches {
+		types = append(types, records[i].Record.Type)
+	}
+	fmt.Printf("ERROR - %s has %d entries (%s), select one with --type\n", name, len(matches), strings.Join(types, ", "))
+	os.Exit(1)
+	return -1
+}
+
+// saveRecords validates the updated records and writes them in the layout of fmt,
+// the change is refused when it introduces an error. It reports if the file is written.
+func saveRecords(records []models.Records, updated []models.Records) bool {
+	// every entry is written like fmt would, the invalid names are reported below
+	updated = append([]models.Records(nil), updated...)
+	for _, change := range canonicalRecords(updated) {
+		fmt.Printf("INFO - %s\n", change)
+	}
+	restricted := loadRestricted(flagRestricted)
+	issues := newIssues(recordIssues(records, restricted), recordIssues(updated, restricted))
+	printIssues(issues)
+	if utils.HasErrors(issues) {
+		fmt.Println("ERROR - records file is not changed")
+		os.Exit(1)
+	}
+
+	data, err := encodeRecords(updated)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println("ERROR - fail to convert records")
+		os.Exit(1)
+	}
+	if flagDryRun {
+		original, _ := os.ReadFile(flagRecords)
+		name := strings.TrimPrefix(filepath.ToSlash(flagRecords), "/")
+		fmt.Print(utils.UnifiedDiff("a/"+name, "b/"+name, original, data))
+		fmt.Println("INFO - dry run, records file is not changed")
+		return false
+	}
+	err = os.WriteFile(flagRecords, data, 0644)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println("ERROR - fail to write records")
+		os.Exit(1)
+	}
+	return true
+}
+
+// canonicalRecords puts every entry in the layout of fmt and returns the
+// changes, the entries with an invalid name are left as they are
+func canonicalRecords(records []models.Records) []string {
+	var changes []string
+	for i := range records {
+		entryChanges, err := canonicalRecord(&records[i])
+		if err != nil {
+			continue
+		}
+		changes = append(changes, entryChanges...)
+	}
+	return changes
+}
+
+// encodeRecords sorts the records by --sort and returns the records file
+func encodeRecords(records []models.Records) ([]byte, error) {
+	if err := utils.SortRecords(records, flagSort); err != nil {
+		return nil, err
+	}
+	return utils.MarshalRecords(records)
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+	"github.com/mrinjamul/mrinjamulcf-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagAuthor string
+)
+
+// reviewCmd compares two versions of the records file
+var reviewCmd = &cobra.Command{
+	Use:   "review base [head]",
+	Short: "review the changes between two records files or git refs as Markdown",
+	Long: `Review the changes between two versions of the records file.
+
+base and head are records files or git refs of the records file, e.g.
+origin/main or main:records.json. head defaults to the records file.
+The report lists the added, removed and modified entries, whether the
+author owns them and the violations introduced by the change.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		head := flagRecords
+		if len(args) > 1 {
+			head = args[1]
+		}
+		baseRecords := loadVersion(args[0])
+		headRecords := loadVersion(head)
+		author := flagAuthor
+		if author == "" {
+			author = os.Getenv("GITHUB_ACTOR")
+		}
+
+		diffs := utils.DiffRecords(baseRecords, headRecords, Domain)
+		restricted := loadRestricted(flagRestricted)
+		introduced := newIssues(recordIssues(baseRecords, restricted), recordIssues(headRecords, restricted))
+		var notOwned int
+		if author != "" {
+			for _, diff := range diffs {
+				if !authorOwns(diff, author) {
+					notOwned++
+				}
+			}
+		}
+
+		fmt.Print(reviewMarkdown(args[0], head, author, diffs, introduced))
+		if utils.HasErrors(introduced) || notOwned > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	reviewCmd.Flags().StringVar(&flagAuthor, "author", "", "username of the author of the change (default $GITHUB_ACTOR)")
+	reviewCmd.Flags().StringVarP(&flagRecords, "file", "f", "", "specify the records file")
+	reviewCmd.Flags().StringVarP(&flagRestricted, "restricted", "r", "", "specify the restricted subdomains file")
+	reviewCmd.Flags().StringVar(&flagDomain, "domain", "", "specify the domain name")
+	bindConfigFlag(reviewCmd, "file", "record_file")
+	bindConfigFlag(reviewCmd, "restricted", "restricted_file")
+	bindConfigFlag(reviewCmd, "domain", "domain_name")
+}
+
+// loadVersion loads a records file, a git ref of the records file or a `ref:path`
+func loadVersion(version string) []models.Records {
+	if _, err := os.Stat(version); err == nil {
+		records, err := utils.GetRecords(version)
+		if err != nil {
+			fmt.Println(err)
+			fmt.Printf("ERROR - fail to parse %s\n", version)
+			os.Exit(1)
+		}
+		return records
+	}
+	ref, filename := version, flagRecords
+	if i := strings.Index(version, ":"); i > 0 {
+		ref, filename = version[:i], version[i+1:]
+	}
+	records, err := utils.GitRecords(ref, filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Println(err)
+		fmt.Printf("ERROR - %s is neither a records file nor a git ref\n", version)
+		os.Exit(1)
+	}
+	return records
+}
+
+// recordIssues runs the checks of fmt --check on the records
+func recordIssues(records []models.Records, restricted *utils.RestrictedList) []utils.Issue {
+	records = append([]models.Records(nil), records...)
+	var issues []utils.Issue
+	for _, entry := range records {
+		for field, value := range map[string]string{"type": entry.Record.Type, "name": entry.Record.Name, "content": entry.Record.Content} {
+			if value == "" {
+				issues = append(issues, utils.Issue{Level: utils.LevelError, Name: entry.Record.Name, Message: "record " + field + " cannot be empty", Rule: utils.RuleEmpty + "-" + field})
+			}
+		}
+		if !entry.Record.Proxied && utils.WantsProxied(entry, TypeDefaults) {
+			issues = append(issues, utils.Issue{Level: utils.LevelWarning, Name: entry.Record.Name, Message: "proxied is false", Rule: utils.RuleProxied})
+		}
+	}
+	issues = append(issues, utils.NormalizeRecords(records)...)
+	for _, entry := range records {
+		if rule, ok := restricted.Match(utils.TrimDomain(entry.Record.Name, Domain)); ok {
+			issues = append(issues, utils.Issue{Level: utils.LevelError, Name: entry.Record.Name, Message: fmt.Sprintf("is restricted (matched %s)", rule), Rule: utils.RuleRestricted})
+		}
+	}
+	issues = append(issues, utils.CheckConfusables(records, restricted, Domain)...)
+	issues = append(issues, utils.CheckPrivateIPs(records, flagPrivateIP, PrivateIPAllow)...)
+	issues = append(issues, utils.CheckOwners(records, OwnerPolicy, Domain)...)
+	return issues
+}
+
+// issueKey identifies an issue by its rule so a changed message, e.g. the
+// count of a quota, is not a new issue
+func issueKey(issue utils.Issue) string {
+	if issue.Rule == "" {
+		return issue.String()
+	}
+	return issue.Level + " " + issue.Name + " " + issue.Rule
+}
+
+// newIssues returns the issues of head which are not in base
+func newIssues(base []utils.Issue, head []utils.Issue) []utils.Issue {
+	seen := make(map[string]bool)
+	for _, issue := range base {
+		seen[issueKey(issue)] = true
+	}
+	var introduced []utils.Issue
+	for _, issue := range head {
+		if !seen[issueKey(issue)] {
+			introduced = append(introduced, issue)
+			seen[issueKey(issue)] = true
+		}
+	}
+	return introduced
+}
+
+// authorOwns checks if the author may make the change: the entries added must
+// be claimed for the author and the modified or removed ones owned by the author
+func authorOwns(diff utils.EntryDiff, author string) bool {
+	owner := diff.Base.Owner.Username
+	if diff.Kind == utils.DiffAdded {
+		owner = diff.Head.Owner.Username
+	}
+	return strings.EqualFold(owner, author)
+}
+
+// describeDiff explains what the change does to the entry
+func describeDiff(diff utils.EntryDiff) string {
+	switch diff.Kind {
+	case utils.DiffAdded:
+		return fmt.Sprintf("`%s`", diff.Head.Record.Content)
+	case utils.DiffRemoved:
+		return fmt.Sprintf("`%s`", diff.Base.Record.Content)
+	}
+	base, head := diff.Base, diff.Head
+	var changes []string
+	if base.Record.Content != head.Record.Content {
+		changes = append(changes, fmt.Sprintf("content `%s` → `%s`", base.Record.Content, head.Record.Content))
+	}
+	if base.Record.Proxied != head.Record.Proxied {
+		changes = append(changes, fmt.Sprintf("proxied %t → %t", base.Record.Proxied, head.Record.Proxied))
+	}
+	if base.Record.TTL != head.Record.TTL {
+		changes = append(changes, fmt.Sprintf("ttl %d → %d", base.Record.TTL, head.Record.TTL))
+	}
+	if base.DNSOnly != head.DNSOnly {
+		changes = append(changes, fmt.Sprintf("dns_only %t → %t", base.DNSOnly, head.DNSOnly))
+	}
+	if base.Owner != head.Owner {
+		changes = append(changes, fmt.Sprintf("owner `%s` → `%s`", ownerName(base.Owner), ownerName(head.Owner)))
+	}
+	if base.Repo != head.Repo {
+		changes = append(changes, fmt.Sprintf("repo `%s` → `%s`", base.Repo, head.Repo))
+	}
+	if base.Description != head.Description {
+		changes = append(changes, "description")
+	}
+	return strings.Join(changes, ", ")
+}
+
+// ownerName returns the username of the owner or its email
+func ownerName(owner models.Owner) string {
+	if owner.Username != "" {
+		return owner.Username
+	}
+	if owner.Email != "" {
+		return owner.Email
+	}
+	return "none"
+}
+
+// reviewMarkdown renders the review as a PR comment
+func reviewMarkdown(base string, head string, author string, diffs []utils.EntryDiff, introduced []utils.Issue) string {
+	var b strings.Builder
+	b.WriteString("## DNS records review\n\n")
+	fmt.Fprintf(&b, "Comparing `%s` with `%s`", base, head)
+	if author != "" {
+		fmt.Fprintf(&b, " for @%s", author)
+	}
+	b.WriteString(".\n\n")
+
+	if len(diffs) == 0 {
+		b.WriteString("No entries changed.\n\n")
+	} else {
+		fmt.Fprintf(&b, "%d added, %d modified, %d removed.\n\n",
+			len(utils.DiffsWith(diffs, utils.DiffAdded)), len(utils.DiffsWith(diffs, utils.DiffModified)), len(utils.DiffsWith(diffs, utils.DiffRemoved)))
+		b.WriteString("| Change | Name | Type | Details | Owner |")
+		if author != "" {
+			b.WriteString(" Author owns it |")
+		}
+		b.WriteString("\n|---|---|---|---|---|")
+		if author != "" {
+			b.WriteString("---|")
+		}
+		b.WriteString("\n")
+		for _, diff := range diffs {
+			owner := diff.Base.Owner
+			if diff.Kind == utils.DiffAdded {
+				owner = diff.Head.Owner
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | %s | %s | %s |", diff.Kind, diff.Name, diff.Type, markdownCell(describeDiff(diff)), markdownCell(ownerName(owner)))
+			if author != "" {
+				if authorOwns(diff, author) {
+					b.WriteString(" ✅ yes |")
+				} else {
+					b.WriteString(" ❌ no |")
+				}
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("### Violations introduced\n\n")
+	if len(introduced) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, issue := range introduced {
+		fmt.Fprintf(&b, "- **%s** `%s`: %s\n", issue.Level, issue.Name, markdownCell(issue.Message))
+	}
+	return b.String()
+}
+
+// markdownCell escapes the text for a table cell
+func markdownCell(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "|", "\\|"), "\n", " ")
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	flagServeInterval time.Duration
+	flagJitter        time.Duration
+	flagPoll          time.Duration
+	flagMaxBackoff    time.Duration
+	flagLockDir       string
+)
+
+// reconcileRetry is the first wait after a failed reconcile, it doubles with every failure
+const reconcileRetry = 30 * time.Second
+
+// syncFlags are the flags of serve passed on to sync
+var syncFlags = []string{"config", "profile", "zone", "proxied", "file", "restricted", "domain", "all-zones", "only", "dry-run", "skip-preflight", "lock-dir"}
+
+// serveCmd keeps the zones in sync with the records file
+var serveCmd = &cobra.Command{
+	Use:     "serve",
+	Aliases: []string{"watch"},
+	Short:   "keep the zone in sync with the records file",
+	Long: `Keep the zone in sync with the records file.
+
+Runs sync at every interval, with a random jitter, and as soon as the records
+or restricted file changes. A failed sync is retried with an exponential
+backoff. A lock file makes sure only one instance, or a manual sync,
+reconciles a zone at a time. SIGINT or SIGTERM stops the command after the
+running sync.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if flagServeInterval <= 0 || flagPoll <= 0 {
+			fmt.Println("ERROR - --interval and --poll must be positive")
+			os.Exit(1)
+		}
+		rand.Seed(time.Now().UnixNano())
+
+		var locks []*utils.Lock
+		release := func() {
+			for _, lock := range locks {
+				if err := lock.Release(); err != nil {
+					fmt.Println(err)
+				}
+			}
+		}
+		for _, zone := range Zones {
+			lock, err := utils.AcquireLock(utils.LockPath(flagLockDir, zone.DomainName))
+			if err != nil {
+				release()
+				fmt.Println(err)
+				fmt.Printf("ERROR - %s is already reconciled by another instance\n", zone.DomainName)
+				os.Exit(1)
+			}
+			locks = append(locks, lock)
+		}
+		defer release()
+
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+		syncArgs := syncArguments(cmd)
+		files := watchedFiles()
+		seen := fileStates(files)
+		poll := time.NewTicker(flagPoll)
+		defer poll.Stop()
+		next := time.Now()
+		var failures int
+		for {
+			// a signal received during the last sync stops before the next one
+			select {
+			case sig := <-stop:
+				fmt.Printf("INFO - %s received, stopping\n", sig)
+				return
+			default:
+			}
+			if !time.Now().Before(next) {
+				wait := flagServeInterval + jitter()
+				if err := reconcile(syncArgs); err != nil {
+					failures++
+					wait = backoff(failures)
+					fmt.Println(err)
+					fmt.Printf("ERROR - sync failed %d time(s) in a row, retrying in %s\n", failures, wait.Round(time.Second))
+				} else {
+					failures = 0
+					fmt.Printf("INFO - next sync in %s\n", wait.Round(time.Second))
+				}
+				next = time.Now().Add(wait)
+			}
+			select {
+			case <-poll.C:
+				current := fileStates(files)
+				for _, file := range files {
+					if current[file] != seen[file] {
+						fmt.Printf("INFO - %s changed\n", file)
+						next = time.Now()
+					}
+				}
+				seen = current
+			case sig := <-stop:
+				fmt.Printf("INFO - %s received, stopping\n", sig)
+				return
+			}
+		}
+	},
+}
+
+func init() {
+	serveCmd.Flags().DurationVar(&flagServeInterval, "interval", 5*time.Minute, "time between two syncs")
+	serveCmd.Flags().DurationVar(&flagJitter, "jitter", 30*time.Second, "random time added to the interval")
+	serveCmd.Flags().DurationVar(&flagPoll, "poll", 2*time.Second, "time between two checks of the files")
+	serveCmd.Flags().DurationVar(&flagMaxBackoff, "max-backoff", 30*time.Minute, "longest wait after failed syncs")
+	serveCmd.Flags().StringVar(&flagLockDir, "lock-dir", "", "directory of the lock files (default $XDG_CACHE_HOME/mrinjamulcf/locks)")
+	serveCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "dry run every sync")
+	serveCmd.Flags().BoolVarP(&flagProxied, "proxied", "p", false, "set all records proxied")
+	serveCmd.Flags().StringVarP(&flagRecords, "file", "f", "", "specify the records file")
+	serveCmd.Flags().StringVarP(&flagRestricted, "restricted", "r", "", "specify the restricted subdomains file")
+	serveCmd.Flags().StringVar(&flagDomain, "domain", "", "specify the domain name")
+	serveCmd.Flags().BoolVar(&flagAllZones, "all-zones", false, "sync every configured zone")
+	serveCmd.Flags().StringArrayVar(&flagFilter, "only", nil, filterHelp)
+	serveCmd.Flags().BoolVar(&flagSkipPreflight, "skip-preflight", false, "do not check the token permissions before syncing")
+	bindConfigFlag(serveCmd, "file", "record_file")
+	bindConfigFlag(serveCmd, "restricted", "restricted_file")
+	bindConfigFlag(serveCmd, "domain", "domain_name")
+}
+
+// syncArguments returns the arguments of sync with the flags given to serve,
+// the sync does not lock the zones serve already holds
+func syncArguments(cmd *cobra.Command) []string {
+	args := []string{"sync", "--lock-held"}
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if !utils.TypeContains(syncFlags, flag.Name) {
+			return
+		}
+		if values, ok := flag.Value.(pflag.SliceValue); ok {
+			for _, value := range values.GetSlice() {
+				args = append(args, "--"+flag.Name+"="+value)
+			}
+			return
+		}
+		args = append(args, "--"+flag.Name+"="+flag.Value.String())
+	})
+	return args
+}
+
+// reconcile runs sync in a new process so a failure does not stop serve,
+// the signals are left to serve which stops once the sync is done
+func reconcile(args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("INFO - sync started at %s\n", time.Now().Format(time.RFC3339))
+	sync := exec.Command(exe, args...)
+	sync.Stdout, sync.Stderr = os.Stdout, os.Stderr
+	detach(sync)
+	return sync.Run()
+}
+
+// jitter returns a random duration up to --jitter
+func jitter() time.Duration {
+	if flagJitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(flagJitter)))
+}
+
+// backoff returns the wait after the failures, doubling up to --max-backoff
+func backoff(failures int) time.Duration {
+	wait := reconcileRetry
+	for i := 1; i < failures && wait < flagMaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > flagMaxBackoff {
+		wait = flagMaxBackoff
+	}
+	return wait + jitter()
+}
+
+// watchedFiles returns the records and restricted files of the zones
+func watchedFiles() []string {
+	var files []string
+	for _, zone := range Zones {
+		for _, file := range []string{zone.RecordFile, zone.RestrictedFile} {
+			if file != "" && !utils.TypeContains(files, file) {
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+// fileStates returns the modification time and size of the files, a missing file is empty
+func fileStates(files []string) map[string]string {
+	states := make(map[string]string)
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			states[file] = fmt.Sprintf("%d %d", info.ModTime().UnixNano(), info.Size())
+		}
+	}
+	return states
+}
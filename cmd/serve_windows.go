@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach runs the command in its own process group, so a console interrupt
+// sent to serve does not stop a sync halfway
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -13,14 +14,19 @@ import (
 )
 
 var (
-	flagDryRun  bool
-	flagProxied bool
-	flagDomain  string
+	flagDryRun        bool
+	flagProxied       bool
+	flagDomain        string
+	flagSkipPreflight bool
+	flagSyncNames     []string
+	flagSyncOwners    []string
+	flagSince         string
+	flagLockHeld      bool
 )
 
 var (
 	// BaseAPI is the base url for cloudflare api
-	BaseAPI string = "https://api.cloudflare.com/client/v4/"
+	BaseAPI string = utils.CloudflareAPI
 	// DomainName sets the domain name
 	Domain string = "mrinjamul.in"
 	// Endpoint specifies the endpoint of the cloudflare api
@@ -35,154 +41,268 @@ var syncCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "sync with remote DNS.",
 	Run: func(cmd *cobra.Command, args []string) {
+		runSync()
+	},
+}
 
-		fmt.Println("mrinjamul.in CLI is running 🌟")
-		fmt.Println("sync started...")
+// planCmd shows what sync would change
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "show what sync would change without changing it",
+	Run: func(cmd *cobra.Command, args []string) {
+		flagDryRun = true
+		runSync()
+	},
+}
 
-		// Set domain name if flag exists
-		if flagDomain != "" {
-			Domain = flagDomain
-		}
-		// Set enabled records if it is null
-		if len(EnabledRecordType) == 0 {
-			EnabledRecordType = []string{"A", "CNAME"}
-		}
+func init() {
+	syncCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "dry run the sync")
+	syncCmd.Flags().StringVar(&flagLockDir, "lock-dir", "", "directory of the lock files (default $XDG_CACHE_HOME/mrinjamulcf/locks)")
+	// serve holds the locks of its zones while the sync it started runs
+	syncCmd.Flags().BoolVar(&flagLockHeld, "lock-held", false, "the zone locks are held by serve")
+	syncCmd.Flags().MarkHidden("lock-held")
+	for _, cmd := range []*cobra.Command{syncCmd, planCmd} {
+		cmd.Flags().BoolVarP(&flagProxied, "proxied", "p", false, "set all records proxied")
+		cmd.Flags().StringVarP(&flagRecords, "file", "f", "", "specify the records file")
+		cmd.Flags().StringVarP(&flagRestricted, "restricted", "r", "", "specify the restricted subdomains file")
+		cmd.Flags().StringVar(&flagDomain, "domain", "", "specify the domain name")
+		cmd.Flags().BoolVar(&flagAllZones, "all-zones", false, "sync every configured zone")
+		cmd.Flags().StringArrayVar(&flagFilter, "only", nil, filterHelp)
+		cmd.Flags().StringArrayVar(&flagSyncNames, "name", nil, "only sync the named record, can be repeated")
+		cmd.Flags().StringArrayVar(&flagSyncOwners, "owner", nil, "only sync the records of the owner (username or email), can be repeated")
+		cmd.Flags().StringVar(&flagSince, "since", "", "only sync the entries changed since the git ref e.g. origin/main")
+		cmd.Flags().BoolVar(&flagSkipPreflight, "skip-preflight", false, "do not check the token permissions before syncing")
+		bindConfigFlag(cmd, "file", "record_file")
+		bindConfigFlag(cmd, "restricted", "restricted_file")
+		bindConfigFlag(cmd, "domain", "domain_name")
+	}
+}
 
-		// gather from remote
-		fmt.Println("INFO - gathering DNS Records from cloudflare api...")
-		registeredRecords := GetRecords(EnabledRecordType)
-		fmt.Printf("INFO - got %d registered DNS Records on cf \n", len(registeredRecords))
-		// gather from local
-		if flagRecords == "" {
-			flagRecords = "records.json"
+// runSync syncs every selected zone
+func runSync() {
+	fmt.Println("mrinjamul.in CLI is running 🌟")
+	fmt.Println("sync started...")
+	if flagDryRun {
+		fmt.Println("INFO - dry run, nothing is changed")
+	}
+	parseFilters()
+
+	for _, zone := range Zones {
+		useZone(zone)
+		// the lock keeps a manual sync and serve from changing the zone together,
+		// a dry run changes nothing and does not wait for it
+		if flagDryRun || flagLockHeld {
+			syncZone()
+			continue
 		}
-		fmt.Println("INFO - gathering DNS Records from repository...")
-		localRecords, err := utils.GetDNSRecords(flagRecords, EnabledRecordType)
+		lock, err := utils.AcquireLock(utils.LockPath(flagLockDir, zone.DomainName))
 		if err != nil {
 			fmt.Println(err)
-			fmt.Println("ERROR - fail to parse local DNS records")
+			fmt.Printf("ERROR - %s is already reconciled by another instance\n", zone.DomainName)
 			os.Exit(1)
 		}
-		for id := range localRecords {
-			if flagProxied {
-				// enable always proxied
-				localRecords[id].Proxied = true
-			}
-			localRecords[id].TTL = 1
-			if localRecords[id].Name == "@" {
-				localRecords[id].Name = Domain
-			} else {
-				localRecords[id].Name = localRecords[id].Name + "." + Domain
-			}
+		syncZone()
+		if err := lock.Release(); err != nil {
+			fmt.Println(err)
 		}
-		fmt.Printf("INFO - got %d local CNAME Records in repo \n", len(localRecords))
+	}
+	fmt.Println("")
+	fmt.Println("sync completed 🎉")
+}
+
+// syncZone syncs the records of the zone in use
+func syncZone() {
+	connectZone()
+	if !flagSkipPreflight {
+		preflight()
+	}
+	// gather from remote
+	fmt.Println("INFO - gathering DNS Records from cloudflare api...")
+	registeredRecords := GetRecords(EnabledRecordType)
+	fmt.Printf("INFO - got %d registered DNS Records on cf \n", len(registeredRecords))
+	// gather from local
+	fmt.Println("INFO - gathering DNS Records from repository...")
+	entries := localEntries()
+	fmt.Printf("INFO - got %d local CNAME Records in repo \n", len(entries))
 
-		// remove restricted subdomains
-		if flagRestricted == "" {
-			flagRestricted = "restricted.json"
+	// restricted subdomains are never synced
+	fmt.Println("INFO - removing restricted subdomains...")
+	restricted := loadRestricted(flagRestricted)
+	changes := utils.PlanChanges(entries, registeredRecords, restricted, Domain)
+	removed := len(utils.ChangesWith(changes, utils.StatusRestricted))
+	fmt.Printf("INFO - got %d local CNAME Records after removing restricted subdomains \n", len(entries)-removed)
+	fmt.Printf("INFO - removed %d restricted subdomains \n", removed)
+	if len(flagFilter) > 0 {
+		changes = filterChanges(changes, parseFilters())
+		fmt.Printf("INFO - only syncing the %d record(s) matching --only \n", len(changes))
+	}
+	if flagSince != "" {
+		changes = changedSince(changes, flagSince)
+	}
+	if len(flagSyncNames) > 0 || len(flagSyncOwners) > 0 {
+		selected, missing, err := utils.SelectChanges(changes, flagSyncNames, flagSyncOwners, Domain)
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println("ERROR - invalid name in --name")
+			os.Exit(1)
 		}
-		fmt.Println("INFO - removing restricted subdomains...")
-		localRecords, removedRecords := utils.RemoveRestrictedSubdomains(flagRestricted, localRecords)
-		fmt.Printf("INFO - got %d local CNAME Records after removing restricted subdomains \n", len(localRecords))
-		fmt.Printf("INFO - removed %d restricted subdomains \n", len(removedRecords))
-
-		var createdRecords []models.Record
-		var updatedRecords []models.Record
-
-		fmt.Println("INFO - inspecting DNS records ..")
-
-		for _, record := range localRecords {
-			r := utils.FindRecordByName(registeredRecords, record.Name)
-			if r.ID != "" {
-				if r.Content != record.Content || r.Proxied != record.Proxied || r.Name != record.Name {
-					record.ID = r.ID
-					updatedRecords = append(updatedRecords, record)
-				}
-			} else {
-				createdRecords = append(createdRecords, record)
-			}
+		for _, name := range missing {
+			fmt.Printf("WARN - %s: not found in the records file nor in the zone\n", name)
 		}
-		fmt.Printf("INFO - found %d DNS Records to create \n", len(createdRecords))
-		fmt.Printf("INFO - found %d DNS Records to update \n", len(updatedRecords))
-
-		// Create records from the list
-		if len(createdRecords) > 0 {
-			fmt.Println(" INFO - Creating DNS Record(s):")
-			for _, r := range createdRecords {
-				postBody, err := json.Marshal(r)
-				if err != nil {
-					fmt.Println(err)
-					fmt.Println("ERROR - fail to marshal record while creating")
-					os.Exit(1)
-				}
-				if !flagDryRun {
-					newRecords := CreateRecord(postBody)
-					r = newRecords
-				}
-				fmt.Printf("%s %s: %s %s\n", r.ID, r.Type, r.Name, r.Content)
+		changes = selected
+		fmt.Printf("INFO - only syncing the %d selected record(s) \n", len(changes))
+	}
+
+	var createdRecords []models.Record
+	var updatedRecords []models.Record
+
+	fmt.Println("INFO - inspecting DNS records ..")
+
+	for _, change := range utils.ChangesWith(changes, utils.StatusCreate) {
+		createdRecords = append(createdRecords, change.Local.Record)
+	}
+	for _, change := range utils.ChangesWith(changes, utils.StatusUpdate) {
+		record := change.Local.Record
+		record.ID = change.Remote.ID
+		updatedRecords = append(updatedRecords, record)
+	}
+	fmt.Printf("INFO - found %d DNS Records to create \n", len(createdRecords))
+	fmt.Printf("INFO - found %d DNS Records to update \n", len(updatedRecords))
+
+	// Create records from the list
+	if len(createdRecords) > 0 {
+		fmt.Println(" INFO - Creating DNS Record(s):")
+		for _, r := range createdRecords {
+			postBody, err := json.Marshal(r)
+			if err != nil {
+				fmt.Println(err)
+				fmt.Println("ERROR - fail to marshal record while creating")
+				os.Exit(1)
 			}
-		}
-		// Update records from the list
-		if len(updatedRecords) > 0 {
-			fmt.Println("INFO - Updating DNS Record(s):")
-			for _, r := range updatedRecords {
-				fmt.Println(r)
-				postBody, err := json.Marshal(r)
-				if err != nil {
-					fmt.Println(err)
-					fmt.Println("ERROR - fail to marshal record while updating")
-					os.Exit(1)
-				}
-				if !flagDryRun {
-					r = UpdateRecord(r.ID, postBody)
-				}
-				fmt.Printf("%s %s: %s %s\n", r.ID, r.Type, r.Name, r.Content)
+			if !flagDryRun {
+				newRecords := CreateRecord(postBody)
+				r = newRecords
 			}
+			fmt.Printf("%s %s: %s %s\n", r.ID, r.Type, r.Name, r.Content)
 		}
-		// check for unused records
-		fmt.Println("INFO - checking for deleted DNS records...")
-		var deletedRecords []models.Record
-		// check record which is not in the registeredRecords
-		for _, r := range registeredRecords {
-			if !utils.RecordContain(localRecords, r) {
-				deletedRecords = append(deletedRecords, r)
+	}
+	// Update records from the list
+	if len(updatedRecords) > 0 {
+		fmt.Println("INFO - Updating DNS Record(s):")
+		for _, r := range updatedRecords {
+			fmt.Println(r)
+			postBody, err := json.Marshal(r)
+			if err != nil {
+				fmt.Println(err)
+				fmt.Println("ERROR - fail to marshal record while updating")
+				os.Exit(1)
+			}
+			if !flagDryRun {
+				r = UpdateRecord(r.ID, postBody)
 			}
+			fmt.Printf("%s %s: %s %s\n", r.ID, r.Type, r.Name, r.Content)
 		}
-		fmt.Printf("INFO - found %d DNS Records to be delete \n", len(deletedRecords))
-		// Delete unsed records
-		if len(deletedRecords) != 0 {
-			fmt.Println("Deleting DNS Record:")
-			for _, r := range deletedRecords {
-				var result models.DelResponse
-				if !flagDryRun {
-					result = DeleteRecord(r.ID)
-					if result.Result.ID == "" {
-						fmt.Println("ERROR - failed to delete " + r.Type + ":" + r.Name)
-					}
+	}
+	// check for unused records
+	fmt.Println("INFO - checking for deleted DNS records...")
+	var deletedRecords []models.Record
+	// check record which is not in the local records
+	for _, change := range utils.ChangesWith(changes, utils.StatusRemoteOnly) {
+		deletedRecords = append(deletedRecords, change.Remote)
+	}
+	fmt.Printf("INFO - found %d DNS Records to be delete \n", len(deletedRecords))
+	// Delete unsed records
+	if len(deletedRecords) != 0 {
+		fmt.Println("Deleting DNS Record:")
+		for _, r := range deletedRecords {
+			var result models.DelResponse
+			if !flagDryRun {
+				result = DeleteRecord(r.ID)
+				if result.Result.ID == "" {
+					fmt.Println("ERROR - failed to delete " + r.Type + ":" + r.Name)
 				}
-				fmt.Printf("%s: %s %s\n", result.Result.ID, r.Name, r.Content)
 			}
-		} else {
-			fmt.Println("INFO - found none")
+			fmt.Printf("%s: %s %s\n", result.Result.ID, r.Name, r.Content)
 		}
-		fmt.Printf("STATUS - %d record(s) created, %d record(s) updated, %d record(s) deleted\n", len(createdRecords), len(updatedRecords), len(deletedRecords))
-		fmt.Println("")
-		fmt.Println("sync completed 🎉")
-	},
+	} else {
+		fmt.Println("INFO - found none")
+	}
+	fmt.Printf("STATUS - %d record(s) created, %d record(s) updated, %d record(s) deleted\n", len(createdRecords), len(updatedRecords), len(deletedRecords))
 }
 
-func init() {
-	syncCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "dry run the sync")
-	syncCmd.Flags().BoolVarP(&flagProxied, "proxied", "p", false, "set all records proxied")
-	syncCmd.Flags().StringVarP(&flagRecords, "file", "f", "", "specify the records file")
-	syncCmd.Flags().StringVarP(&flagRestricted, "restricted", "r", "", "specify the restricted subdomains file")
-	syncCmd.Flags().StringVar(&flagDomain, "domain", "", "specify the domain name")
+// changedSince keeps the changes of the entries added, modified or removed
+// in the records file since the git ref
+func changedSince(changes []utils.Change, ref string) []utils.Change {
+	base, err := utils.GitRecords(ref, flagRecords)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Println(err)
+			fmt.Printf("ERROR - fail to load the records file at %s\n", ref)
+			os.Exit(1)
+		}
+		fmt.Printf("WARN - %v, every entry is new\n", err)
+	}
+	head, err := utils.GetRecords(flagRecords)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println("ERROR - fail to parse local DNS records")
+		os.Exit(1)
+	}
+	diffs := utils.DiffRecords(base, head, Domain)
+	fmt.Printf("INFO - since %s: %d added, %d modified, %d removed entries \n", ref,
+		len(utils.DiffsWith(diffs, utils.DiffAdded)), len(utils.DiffsWith(diffs, utils.DiffModified)), len(utils.DiffsWith(diffs, utils.DiffRemoved)))
+	// an entry whose name is invalid keeps it as is in the diff
+	selected, _, err := utils.SelectChanges(changes, utils.DiffNames(diffs), nil, Domain)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Printf("ERROR - invalid name in the records changed since %s\n", ref)
+		os.Exit(1)
+	}
+	fmt.Printf("INFO - only syncing the %d record(s) changed since %s \n", len(selected), ref)
+	return selected
+}
+
+// localEntries returns the entries of the records file which sync pushes,
+// with the proxy and TTL policy applied and fully qualified names
+func localEntries() []models.Records {
+	entries, err := utils.GetRecords(flagRecords)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println("ERROR - fail to parse local DNS records")
+		os.Exit(1)
+	}
+	var local []models.Records
+	for _, entry := range entries {
+		if !utils.TypeContains(EnabledRecordType, entry.Record.Type) {
+			continue
+		}
+		if flagProxied && utils.IsProxiable(entry.Record.Type) && !entry.DNSOnly {
+			// enable always proxied
+			entry.Record.Proxied = true
+		}
+		utils.ApplyTypeDefaults(&entry, TypeDefaults)
+		// compare the names in lowercase and punycode like the api returns them
+		name, err := utils.CanonicalName(entry.Record.Name, Domain)
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println("ERROR - invalid name in local DNS records")
+			os.Exit(1)
+		}
+		entry.Record.Name = utils.FQDN(name, Domain)
+		local = append(local, entry)
+	}
+	return local
 }
 
 // GetRecords returns all records from cloudflare api
 func GetRecords(recordTypes []string) []models.Record {
+	return utils.Concat(nil, GetResults(recordTypes))
+}
+
+// GetResults returns all records from cloudflare api as the api returns them
+func GetResults(recordTypes []string) []models.Result {
 	query := url.Values{}
-	var records []models.Record
+	var records []models.Result
 	var results []models.Result
 	for _, t := range recordTypes {
 		query.Add("type", t)
@@ -203,7 +323,7 @@ func GetRecords(recordTypes []string) []models.Record {
 				break
 			}
 			results = resp.Result
-			records = utils.Concat(records, results)
+			records = append(records, results...)
 		}
 		query.Del("type")
 	}
@@ -251,3 +371,18 @@ func DeleteRecord(recordID string) models.DelResponse {
 	}
 	return resp
 }
+
+// loadRestricted loads the restricted subdomains, a missing file restricts nothing
+func loadRestricted(filename string) *utils.RestrictedList {
+	restricted, err := utils.LoadRestricted(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "WARN - restricted file %s not found\n", filename)
+			return restricted
+		}
+		fmt.Println(err)
+		fmt.Println("ERROR - fail to parse restricted subdomains")
+		os.Exit(1)
+	}
+	return restricted
+}
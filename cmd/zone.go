@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+	"github.com/mrinjamul/mrinjamulcf-cli/utils"
+)
+
+var (
+	flagProfile  string
+	flagZone     string
+	flagAllZones bool
+	// Zones are the zones selected by --profile, --zone or --all-zones
+	Zones []models.Zone
+	// CurrentZone is the zone the commands work on
+	CurrentZone models.Zone
+)
+
+// resolveZones selects the zones to work on and uses the first one,
+// the flags given to the command overrides the selected zone
+func resolveZones() error {
+	zones, err := utils.ResolveZones(Config, utils.ZoneSelector{
+		Profile: flagProfile,
+		Zone:    flagZone,
+		All:     flagAllZones,
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{"domain_name", "record_file", "restricted_file"} {
+		if ConfigSources[key] != utils.SourceFlag {
+			continue
+		}
+		if len(zones) > 1 {
+			return fmt.Errorf("%s cannot be set by a flag for more than one zone", key)
+		}
+		switch key {
+		case "domain_name":
+			zones[0].DomainName = Config.DomainName
+		case "record_file":
+			zones[0].RecordFile = Config.RecordFile
+		case "restricted_file":
+			zones[0].RestrictedFile = Config.RestrictedFile
+		}
+	}
+	useZone(zones[0])
+	Zones = zones
+	return nil
+}
+
+// useZone points the commands at the zone
+func useZone(zone models.Zone) {
+	CurrentZone = zone
+	Domain, flagDomain = zone.DomainName, zone.DomainName
+	CFToken, ZoneID = "", zone.ZoneID
+	flagRecords, flagRestricted = zone.RecordFile, zone.RestrictedFile
+	EnabledRecordType = zone.RecordType
+	if len(Zones) > 1 {
+		fmt.Fprintf(os.Stderr, "INFO - zone %s (%s)\n", zone.Name, zone.DomainName)
+	}
+}
+
+// connectZone resolves the token and the zone id needed to talk to cloudflare
+func connectZone() {
+	resolveToken()
+	resolveZoneID()
+}
+
+// resolveToken reads the token of the zone in use from its source
+func resolveToken() {
+	if CFToken != "" {
+		return
+	}
+	token, err := utils.ResolveToken(CurrentZone, utils.SecretsFile(Config))
+	if err != nil {
+		fmt.Println(err)
+		fmt.Printf("ERROR - fail to get the cloudflare token of %s\n", Domain)
+		os.Exit(1)
+	}
+	CFToken = token
+}
+
+// resolveZoneID looks up the zone id of the domain when it is not configured
+func resolveZoneID() {
+	if ZoneID != "" {
+		return
+	}
+	id, err := utils.ResolveZoneID(BaseAPI, Domain, CFToken)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Printf("ERROR - fail to resolve the zone id of %s\n", Domain)
+		os.Exit(1)
+	}
+	ZoneID = id
+}
@@ -2,4 +2,11 @@ module github.com/mrinjamul/mrinjamulcf-cli
 
 go 1.16
 
-require github.com/spf13/cobra v1.3.0
+require (
+	github.com/spf13/cobra v1.3.0
+	github.com/spf13/pflag v1.0.5
+	golang.org/x/crypto v0.6.0
+	golang.org/x/net v0.7.0
+	golang.org/x/sys v0.5.0
+	golang.org/x/term v0.5.0
+)
@@ -22,6 +22,7 @@ type Records struct {
 	Description string `json:"description,omitempty"`
 	Repo        string `json:"repo,omitempty"`
 	Owner       Owner  `json:"owner,omitempty"`
+	DNSOnly     bool   `json:"dns_only,omitempty"`
 	Record      Record `json:"record"`
 }
 
@@ -71,6 +72,43 @@ type CFResponse struct {
 	Result     []Result   `json:"result"`
 }
 
+// ZoneInfo is a zone returned by the zones endpoints
+type ZoneInfo struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Status      string   `json:"status"`
+	Permissions []string `json:"permissions"`
+}
+
+// ZoneResponse is the response struct of the zone details endpoint
+type ZoneResponse struct {
+	Success bool     `json:"success"`
+	Errors  []Errors `json:"errors"`
+	Result  ZoneInfo `json:"result"`
+}
+
+// TokenInfo is the result of the token verify endpoint
+type TokenInfo struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	ExpiresOn string `json:"expires_on"`
+}
+
+// TokenResponse is the response struct of the token verify endpoint
+type TokenResponse struct {
+	Success bool      `json:"success"`
+	Errors  []Errors  `json:"errors"`
+	Result  TokenInfo `json:"result"`
+}
+
+// ZonesResponse is the response struct of the zones list endpoint
+type ZonesResponse struct {
+	Success    bool       `json:"success"`
+	Errors     []Errors   `json:"errors"`
+	ResultInfo ResultInfo `json:"result_info"`
+	Result     []ZoneInfo `json:"result"`
+}
+
 // CFResponse is the response struct we get from the API using POST method
 type PostResponse struct {
 	Success    bool         `json:"success"`
@@ -93,10 +131,57 @@ type DelResponse struct {
 
 // Config is the struct for the config file
 type Config struct {
-	CFToken        string   `json:"cf_token"`
-	ZoneID         string   `json:"zone_id"`
-	DomainName     string   `json:"domain_name"`
-	RecordFile     string   `json:"record_file"`
-	RestrictedFile string   `json:"restricted_file"`
-	RecordType     []string `json:"record_type"`
+	CFToken        string                 `json:"cf_token,omitempty"`
+	CFTokenFile    string                 `json:"cf_token_file,omitempty"`
+	TokenCommand   string                 `json:"token_command,omitempty"`
+	SecretsFile    string                 `json:"secrets_file,omitempty"`
+	ZoneID         string                 `json:"zone_id,omitempty"`
+	DomainName     string                 `json:"domain_name,omitempty"`
+	RecordFile     string                 `json:"record_file,omitempty"`
+	RestrictedFile string                 `json:"restricted_file,omitempty"`
+	RecordType     []string               `json:"record_type,omitempty"`
+	PrivateIP      string                 `json:"private_ip,omitempty"`
+	PrivateIPAllow []string               `json:"private_ip_allow,omitempty"`
+	Owners         OwnerPolicy            `json:"owners,omitempty"`
+	GitHubAPI      string                 `json:"github_api,omitempty"`
+	APIURL         string                 `json:"api_url,omitempty"`
+	IPv4URL        string                 `json:"ipv4_url,omitempty"`
+	IPv6URL        string                 `json:"ipv6_url,omitempty"`
+	SortBy         string                 `json:"sort_by,omitempty"`
+	TypeDefaults   map[string]TypeDefault `json:"type_defaults,omitempty"`
+	Profile        string                 `json:"profile,omitempty"`
+	Zones          []Zone                 `json:"zones,omitempty"`
+}
+
+// Zone is a named profile for a domain, the fields which are not set are
+// taken from the top level config
+type Zone struct {
+	Name           string   `json:"name"`
+	CFToken        string   `json:"cf_token,omitempty"`
+	CFTokenFile    string   `json:"cf_token_file,omitempty"`
+	TokenCommand   string   `json:"token_command,omitempty"`
+	ZoneID         string   `json:"zone_id,omitempty"`
+	DomainName     string   `json:"domain_name,omitempty"`
+	RecordFile     string   `json:"record_file,omitempty"`
+	RestrictedFile string   `json:"restricted_file,omitempty"`
+	RecordType     []string `json:"record_type,omitempty"`
+}
+
+// TypeDefault is the default proxied state and TTL of a record type
+type TypeDefault struct {
+	Proxied *bool `json:"proxied,omitempty"`
+	TTL     uint  `json:"ttl,omitempty"`
+}
+
+// OwnerLimit is the limit for the subdomains claimed by an owner
+type OwnerLimit struct {
+	MaxSubdomains int      `json:"max_subdomains,omitempty"`
+	AllowedTypes  []string `json:"allowed_types,omitempty"`
+}
+
+// OwnerPolicy is the ownership rules for the records file
+type OwnerPolicy struct {
+	Optional  bool                  `json:"optional,omitempty"`
+	Default   OwnerLimit            `json:"default"`
+	Overrides map[string]OwnerLimit `json:"overrides,omitempty"`
 }
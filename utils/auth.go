@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// Permissions of the token on a zone as listed by the zone details endpoint
+const (
+	PermDNSRead = "#dns_records:read"
+	PermDNSEdit = "#dns_records:edit"
+)
+
+// TokensPage is where the API tokens are managed
+const TokensPage = "https://dash.cloudflare.com/profile/api-tokens"
+
+// tokenExpiryWarning is how long before the expiry of the token a warning is shown
+const tokenExpiryWarning = 7 * 24 * time.Hour
+
+// VerifyToken checks that the token is valid and active
+func VerifyToken(base string, token string) []Issue {
+	var issues []Issue
+	if token == "" {
+		return append(issues, Issue{Level: LevelError, Name: "token", Message: "is empty, set cf_token, cf_token_file, token_command or secrets_file"})
+	}
+	var result models.TokenResponse
+	status, err := cfGet(base, "user/tokens/verify", token, &result)
+	if err != nil && status == 0 {
+		return append(issues, Issue{Level: LevelError, Name: "token", Message: fmt.Sprintf("cannot reach %s: %v", base, ScrubError(err))})
+	}
+	if err != nil {
+		return append(issues, Issue{Level: LevelError, Name: "token", Message: ScrubError(err).Error()})
+	}
+	if !result.Success {
+		message := cfError(result.Errors, status).Error()
+		if status == http.StatusUnauthorized || status == http.StatusForbidden || status == http.StatusBadRequest {
+			message = fmt.Sprintf("is not valid (%s), create an API token at %s", message, TokensPage)
+		}
+		return append(issues, Issue{Level: LevelError, Name: "token", Message: message})
+	}
+	if result.Result.Status != "active" {
+		return append(issues, Issue{Level: LevelError, Name: "token", Message: fmt.Sprintf("is %s, activate it or create a new one at %s", result.Result.Status, TokensPage)})
+	}
+	if result.Result.ExpiresOn != "" {
+		expires, err := time.Parse(time.RFC3339, result.Result.ExpiresOn)
+		if err == nil && time.Until(expires) < tokenExpiryWarning {
+			issues = append(issues, Issue{Level: LevelWarning, Name: "token", Message: fmt.Sprintf("expires on %s, roll it at %s", expires.Format("2006-01-02"), TokensPage)})
+		}
+	}
+	return issues
+}
+
+// CheckZoneAccess checks that the token can read the dns records of the zone
+// and, with edit, change them
+func CheckZoneAccess(base string, token string, zoneID string, domain string, edit bool) []Issue {
+	var issues []Issue
+	var zone models.ZoneResponse
+	status, err := cfGet(base, "zones/"+zoneID, token, &zone)
+	if err != nil {
+		return append(issues, Issue{Level: LevelError, Name: domain, Message: ScrubError(err).Error()})
+	}
+	if !zone.Success {
+		return append(issues, Issue{
+			Level:   LevelError,
+			Name:    domain,
+			Message: fmt.Sprintf("the token cannot read zone %s (%v), add the Zone Read permission for %s to the token", zoneID, cfError(zone.Errors, status), domain),
+		})
+	}
+	// domain names are not case-sensitive and the domain may be fully qualified
+	if zone.Result.Name != "" && !strings.EqualFold(zone.Result.Name, strings.TrimSuffix(domain, ".")) {
+		issues = append(issues, Issue{Level: LevelError, Name: domain, Message: fmt.Sprintf("zone %s is %s, check zone_id and domain_name", zoneID, zone.Result.Name)})
+	}
+
+	var records models.CFResponse
+	status, err = cfGet(base, "zones/"+zoneID+"/dns_records?per_page=1", token, &records)
+	if err != nil {
+		return append(issues, Issue{Level: LevelError, Name: domain, Message: ScrubError(err).Error()})
+	}
+	if !records.Success {
+		return append(issues, Issue{
+			Level:   LevelError,
+			Name:    domain,
+			Message: fmt.Sprintf("the token cannot read the DNS records (%v), add the DNS Read permission for %s to the token", cfError(records.Errors, status), domain),
+		})
+	}
+	if !edit {
+		return issues
+	}
+
+	// the zone lists the permissions of the token, without them edit cannot be confirmed
+	if len(zone.Result.Permissions) == 0 {
+		return append(issues, Issue{Level: LevelWarning, Name: domain, Message: "cannot confirm the DNS Edit permission, the API does not list the permissions of the token"})
+	}
+	if !TypeContains(zone.Result.Permissions, PermDNSEdit) {
+		issues = append(issues, Issue{Level: LevelError, Name: domain, Message: fmt.Sprintf("the token cannot edit the DNS records, add the DNS Edit permission for %s to the token", domain)})
+	}
+	return issues
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// fakeCloudflare serves the token and zone endpoints of the api and points CF_API_URL at it,
+// the token "edit" can edit the DNS records of zone z1, "read" can only read them
+func fakeCloudflare(t *testing.T) string {
+	t.Helper()
+	permissions := map[string]string{
+		"edit": fmt.Sprintf(`["#zone:read","%s","%s"]`, PermDNSRead, PermDNSEdit),
+		"read": fmt.Sprintf(`["#zone:read","%s"]`, PermDNSRead),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		perms, ok := permissions[token]
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"success":false,"errors":[{"code":1000,"message":"Invalid API Token"}]}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/client/v4/user/tokens/verify":
+			fmt.Fprintf(w, `{"success":true,"errors":[],"result":{"id":"%s","status":"active"}}`, token)
+		case "/client/v4/zones/z1":
+			fmt.Fprintf(w, `{"success":true,"errors":[],"result":{"id":"z1","name":"example.com","status":"active","permissions":%s}}`, perms)
+		case "/client/v4/zones/z1/dns_records":
+			fmt.Fprint(w, `{"success":true,"errors":[],"result":[]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"success":false,"errors":[{"code":7003,"message":"Could not route"}]}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("CF_API_URL", server.URL+"/client/v4")
+	return APIBase(os.Getenv("CF_API_URL"))
+}
+
+func TestVerifyToken(t *testing.T) {
+	base := fakeCloudflare(t)
+	if issues := VerifyToken(base, "edit"); len(issues) != 0 {
+		t.Errorf("VerifyToken(valid) = %v, want no issue", issues)
+	}
+	issues := VerifyToken(base, "revoked")
+	if len(issues) != 1 || issues[0].Level != LevelError || !strings.Contains(issues[0].Message, "is not valid (Invalid API Token") {
+		t.Errorf("VerifyToken(401) = %v, want the token to be invalid", issues)
+	}
+	if issues := VerifyToken(base, ""); !HasErrors(issues) {
+		t.Errorf("VerifyToken(empty) = %v, want an error", issues)
+	}
+}
+
+func TestCheckZoneAccess(t *testing.T) {
+	base := fakeCloudflare(t)
+	if issues := CheckZoneAccess(base, "edit", "z1", "example.com", true); len(issues) != 0 {
+		t.Errorf("CheckZoneAccess(valid) = %v, want no issue", issues)
+	}
+	if issues := CheckZoneAccess(base, "read", "z1", "example.com", false); len(issues) != 0 {
+		t.Errorf("CheckZoneAccess(read only, no edit) = %v, want no issue", issues)
+	}
+	issues := CheckZoneAccess(base, "read", "z1", "example.com", true)
+	if len(issues) != 1 || issues[0].Level != LevelError || !strings.Contains(issues[0].Message, "cannot edit the DNS records") {
+		t.Errorf("CheckZoneAccess(missing %s) = %v, want an error", PermDNSEdit, issues)
+	}
+	issues = CheckZoneAccess(base, "revoked", "z1", "example.com", true)
+	if len(issues) != 1 || issues[0].Level != LevelError || !strings.Contains(issues[0].Message, "cannot read zone z1 (") {
+		t.Errorf("CheckZoneAccess(401) = %v, want an error", issues)
+	}
+	if issues := CheckZoneAccess(base, "edit", "z1", "Example.COM.", true); len(issues) != 0 {
+		t.Errorf("CheckZoneAccess(upper case domain) = %v, want no issue", issues)
+	}
+	if issues := CheckZoneAccess(base, "edit", "z1", "other.com", true); !HasErrors(issues) {
+		t.Errorf("CheckZoneAccess(other domain) = %v, want an error", issues)
+	}
+}
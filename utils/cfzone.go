@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// ZoneCachePath returns the file caching the zone ids in $XDG_CACHE_HOME
+func ZoneCachePath() string {
+	return filepath.Join(CacheDir(), "zones.json")
+}
+
+// CacheDir returns the cache directory in $XDG_CACHE_HOME
+func CacheDir() string {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		dir = filepath.Join(HomeDir(), ".cache")
+	}
+	return filepath.Join(dir, "mrinjamulcf")
+}
+
+// readZoneCache reads the cached domain to zone id mapping
+func readZoneCache(filename string) map[string]string {
+	cache := make(map[string]string)
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return cache
+	}
+	// a broken cache is ignored and rewritten
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return make(map[string]string)
+	}
+	return cache
+}
+
+// writeZoneCache writes the domain to zone id mapping
+func writeZoneCache(filename string, cache map[string]string) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(data, '\n'), 0600)
+}
+
+// CloudflareAPI is the default base url of the cloudflare api
+const CloudflareAPI = "https://api.cloudflare.com/client/v4/"
+
+// APIBase returns the base url with the trailing slash the endpoints are appended to
+func APIBase(base string) string {
+	if base == "" {
+		return CloudflareAPI
+	}
+	return strings.TrimSuffix(base, "/") + "/"
+}
+
+// cfGet sends a GET request and decodes the response into v,
+// the http status is returned with the decoding errors
+func cfGet(base string, endpoint string, token string, v interface{}) (int, error) {
+	req, err := http.NewRequest("GET", base+endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Add("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return resp.StatusCode, fmt.Errorf("cannot parse the response of %s (%s): %v", endpoint, resp.Status, err)
+	}
+	return resp.StatusCode, nil
+}
+
+// cfError returns the first error of the response
+func cfError(errors []models.Errors, status int) error {
+	if len(errors) > 0 {
+		return fmt.Errorf("%s (code %d)", Scrub(errors[0].Message), errors[0].Code)
+	}
+	return fmt.Errorf("request failed with status %d", status)
+}
+
+// CFFetchZones lists the zones named domain which the token can see
+func CFFetchZones(base string, domain string, token string) ([]models.ZoneInfo, error) {
+	var result models.ZonesResponse
+	query := url.Values{}
+	query.Set("name", domain)
+	query.Set("per_page", "50")
+	status, err := cfGet(base, "zones?"+query.Encode(), token, &result)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success || len(result.Errors) > 0 {
+		return nil, cfError(result.Errors, status)
+	}
+	return result.Result, nil
+}
+
+// LookupZoneID finds the id of the zone named domain
+func LookupZoneID(base string, domain string, token string) (string, error) {
+	zones, err := CFFetchZones(base, domain, token)
+	if err != nil {
+		return "", err
+	}
+	switch len(zones) {
+	case 0:
+		return "", fmt.Errorf("zone %q is not visible to the token, check the domain name and that the token has Zone Read permission on it", domain)
+	case 1:
+		return zones[0].ID, nil
+	}
+	var ids []string
+	for _, zone := range zones {
+		ids = append(ids, fmt.Sprintf("%s (%s)", zone.ID, zone.Status))
+	}
+	return "", fmt.Errorf("domain %q matches %d zones: %s, set zone_id to pick one", domain, len(zones), strings.Join(ids, ", "))
+}
+
+// ResolveZoneID returns the zone id of the domain from the cache or the zones list endpoint
+func ResolveZoneID(base string, domain string, token string) (string, error) {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	// the ids of another api e.g. a test server are kept apart
+	key := domain
+	if base != CloudflareAPI {
+		key = base + domain
+	}
+	filename := ZoneCachePath()
+	cache := readZoneCache(filename)
+	if id, ok := cache[key]; ok && id != "" {
+		return id, nil
+	}
+	id, err := LookupZoneID(base, domain, token)
+	if err != nil {
+		return "", err
+	}
+	cache[key] = id
+	if err := writeZoneCache(filename, cache); err != nil {
+		fmt.Fprintf(os.Stderr, "WARN - cannot cache the zone id: %v\n", err)
+	}
+	return id, nil
+}
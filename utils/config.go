@@ -0,0 +1,603 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// Sources of the config values, from the lowest to the highest precedence
+const (
+	SourceDefault = "default"
+	SourceSystem  = "system"
+	SourceUser    = "user"
+	SourceProject = "project"
+	SourceDotEnv  = ".env"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Config files of the layers
+const (
+	SystemConfigFile  = "/etc/mrinjamulcf/config.json"
+	ProjectConfigFile = ".mrinjamulcf.json"
+	DotEnvFile        = ".env"
+)
+
+// ConfigSources maps every config key to the source of its value
+type ConfigSources map[string]string
+
+// ConfigEnv maps the config keys to the environment variables which overrides them
+var ConfigEnv = map[string]string{
+	"cf_token":        "CF_TOK",
+	"cf_token_file":   "CF_TOK_FILE",
+	"zone_id":         "CF_ZID",
+	"domain_name":     "DOMAIN_NAME",
+	"record_file":     "RECORD_FILE",
+	"restricted_file": "RESTRICTED_FILE",
+	"github_api":      "GITHUB_API_URL",
+	"api_url":         "CF_API_URL",
+	"ipv4_url":        "DDNS_IPV4_URL",
+	"ipv6_url":        "DDNS_IPV6_URL",
+	"profile":         "MRINJAMULCF_PROFILE",
+}
+
+// ProjectKeys are the config keys the project file and .env may set. They are
+// read from the checkout, e.g. a cloned repository, so the keys reaching the
+// credentials or the endpoints receiving them must come from the user.
+var ProjectKeys = []string{"domain_name", "zone_id", "record_file", "restricted_file", "record_type", "sort_by", "type_defaults"}
+
+// TokenCommandSources are the sources token_command may come from, the
+// command is run by the shell so it is never read from the checkout
+var TokenCommandSources = []string{SourceDefault, SourceSystem, SourceUser, SourceEnv, SourceFlag}
+
+// SecretKeys are the config keys which are never printed
+var SecretKeys = []string{"cf_token"}
+
+// KnownRecordTypes are the record types the CLI can manage
+var KnownRecordTypes = []string{"A", "AAAA", "CNAME", "TXT", "MX", "SRV"}
+
+// DefaultConfig returns the config used when nothing is configured
+func DefaultConfig() models.Config {
+	return models.Config{
+		DomainName:     "mrinjamul.in",
+		RecordFile:     "records.json",
+		RestrictedFile: "restricted.json",
+		RecordType:     []string{"A", "CNAME"},
+		PrivateIP:      "error",
+		GitHubAPI:      GitHubAPI,
+		APIURL:         CloudflareAPI,
+		IPv4URL:        IPv4Endpoint,
+		IPv6URL:        IPv6Endpoint,
+		SortBy:         SortByName,
+	}
+}
+
+// LegacyConfigPath returns the path of the config file used by older versions
+func LegacyConfigPath() string {
+	return filepath.Join(HomeDir(), ".mrinjamulcli.json")
+}
+
+// XDGConfigPath returns the path of the user config file in $XDG_CONFIG_HOME
+func XDGConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(HomeDir(), ".config")
+	}
+	return filepath.Join(dir, "mrinjamulcf", "config.json")
+}
+
+// ConfigPath returns the path of the user config file, the legacy file is
+// used as long as it exists
+func ConfigPath() string {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return path
+	}
+	if _, err := os.Stat(LegacyConfigPath()); err == nil {
+		return LegacyConfigPath()
+	}
+	return XDGConfigPath()
+}
+
+// ProjectConfigPath returns the project config file in the current
+// directory or the closest parent, empty if there is none
+func ProjectConfigPath() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectConfigFile)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadDotEnv parses a .env file with KEY=VALUE lines
+func LoadDotEnv(filename string) (map[string]string, error) {
+	env := make(map[string]string)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return env, err
+	}
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			return env, fmt.Errorf("%s:%d: expected KEY=VALUE", filename, n+1)
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+// ConfigKeys returns the json keys of the config in declaration order
+func ConfigKeys() []string {
+	var keys []string
+	t := reflect.TypeOf(models.Config{})
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, jsonKey(t.Field(i)))
+	}
+	return keys
+}
+
+// jsonKey returns the json name of the struct field
+func jsonKey(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+// configField returns the field of the config for the key
+func configField(config *models.Config, key string) (reflect.Value, bool) {
+	v := reflect.ValueOf(config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if jsonKey(v.Type().Field(i)) == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// ParseConfig parses a single config file
+func ParseConfig(filename string) (models.Config, error) {
+	var config models.Config
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return config, err
+	}
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("%s: %v", filename, err)
+	}
+	return config, nil
+}
+
+// untrustedKey reports a key set by the project file or .env which is not in ProjectKeys
+func untrustedKey(filename string, key string) error {
+	return fmt.Errorf("%s: %s cannot be set in a project file or .env, set it in the user config or the environment", filename, key)
+}
+
+// applyConfigFile merges the keys present in the file into the config,
+// the project file may only set the ProjectKeys
+func applyConfigFile(config *models.Config, sources ConfigSources, filename string, source string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	var names []string
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	for _, key := range names {
+		raw := keys[key]
+		field, ok := configField(config, key)
+		if !ok {
+			continue
+		}
+		if source == SourceProject && !TypeContains(ProjectKeys, key) {
+			return untrustedKey(filename, key)
+		}
+		value := reflect.New(field.Type())
+		if err := json.Unmarshal(raw, value.Interface()); err != nil {
+			return fmt.Errorf("%s: %s: %v", filename, key, err)
+		}
+		// empty values keeps the value of the lower layer
+		if value.Elem().IsZero() {
+			continue
+		}
+		field.Set(value.Elem())
+		sources[key] = source
+	}
+	return nil
+}
+
+// configLayer is a config file, a missing file is skipped unless it is required
+type configLayer struct {
+	source   string
+	filename string
+	required bool
+}
+
+// LoadedConfig is the merged configuration and where it comes from
+type LoadedConfig struct {
+	Config  models.Config
+	Sources ConfigSources
+	// Files are the loaded files as `layer: path`
+	Files []string
+}
+
+// LoadConfig merges the configuration layers, each one overrides the previous:
+// defaults < system file < user file < project file < .env < environment.
+// The project file and .env may only set the ProjectKeys.
+// The user file is $XDG_CONFIG_HOME/mrinjamulcf/config.json or the legacy
+// $HOME/.mrinjamulcli.json, filename replaces it when given.
+func LoadConfig(filename string) (LoadedConfig, error) {
+	loaded := LoadedConfig{Config: DefaultConfig(), Sources: make(ConfigSources)}
+	for _, key := range ConfigKeys() {
+		loaded.Sources[key] = SourceDefault
+	}
+
+	// files, the one named explicitly is required
+	layers := []configLayer{{SourceSystem, SystemConfigFile, false}}
+	if filename != "" {
+		layers = append(layers, configLayer{SourceUser, filename, true})
+	} else {
+		layers = append(layers,
+			configLayer{SourceUser, LegacyConfigPath(), false},
+			configLayer{SourceUser, XDGConfigPath(), false},
+		)
+	}
+	if project := ProjectConfigPath(); project != "" {
+		layers = append(layers, configLayer{SourceProject, project, false})
+	}
+	for _, layer := range layers {
+		err := applyConfigFile(&loaded.Config, loaded.Sources, layer.filename, layer.source)
+		if err != nil {
+			if os.IsNotExist(err) && !layer.required {
+				continue
+			}
+			return loaded, err
+		}
+		loaded.Files = append(loaded.Files, layer.source+": "+layer.filename)
+		if layer.source == SourceProject {
+			// files named in the project config are relative to it
+			dir := filepath.Dir(layer.filename)
+			if loaded.Sources["record_file"] == SourceProject && !filepath.IsAbs(loaded.Config.RecordFile) {
+				loaded.Config.RecordFile = filepath.Join(dir, loaded.Config.RecordFile)
+			}
+			if loaded.Sources["restricted_file"] == SourceProject && !filepath.IsAbs(loaded.Config.RestrictedFile) {
+				loaded.Config.RestrictedFile = filepath.Join(dir, loaded.Config.RestrictedFile)
+			}
+		}
+	}
+
+	// environment, the variables set in the shell wins over .env
+	dotenv, err := LoadDotEnv(DotEnvFile)
+	if err != nil && !os.IsNotExist(err) {
+		return loaded, err
+	}
+	if err == nil {
+		loaded.Files = append(loaded.Files, SourceDotEnv+": "+DotEnvFile)
+	}
+	for _, key := range ConfigKeys() {
+		env, ok := ConfigEnv[key]
+		if !ok {
+			continue
+		}
+		// .env is in the checkout like the project file
+		if _, set := dotenv[env]; set && !TypeContains(ProjectKeys, key) {
+			return loaded, untrustedKey(DotEnvFile, env)
+		}
+		value, present := os.LookupEnv(env)
+		source := SourceEnv
+		if !present {
+			value, present = dotenv[env]
+			source = SourceDotEnv
+		}
+		if !present || value == "" {
+			continue
+		}
+		if err := SetConfigValue(&loaded.Config, key, value); err != nil {
+			return loaded, fmt.Errorf("%s: %v", env, err)
+		}
+		loaded.Sources[key] = source
+	}
+	keepTokenLayer(&loaded)
+	if issues := checkTokenCommandSource(loaded.Sources); len(issues) > 0 {
+		return loaded, fmt.Errorf("%s %s", issues[0].Name, issues[0].Message)
+	}
+	return loaded, nil
+}
+
+// checkTokenCommandSource reports a token_command, at the top level or in
+// the zones, which does not come from one of the TokenCommandSources
+func checkTokenCommandSource(sources ConfigSources) []Issue {
+	var issues []Issue
+	for _, key := range []string{"token_command", "zones"} {
+		if source, ok := sources[key]; ok && !TypeContains(TokenCommandSources, source) {
+			issues = append(issues, Issue{Level: LevelError, Name: key, Message: fmt.Sprintf("comes from the %s layer, token_command is only read from the system and user files, the environment and the flags", source)})
+		}
+	}
+	return issues
+}
+
+// sourceRank returns the precedence of the config source
+func sourceRank(source string) int {
+	for i, s := range []string{SourceDefault, SourceSystem, SourceUser, SourceProject, SourceDotEnv, SourceEnv, SourceFlag} {
+		if s == source {
+			return i
+		}
+	}
+	return -1
+}
+
+// keepTokenLayer keeps the token sources of the highest layer setting one,
+// e.g. CF_TOK_FILE in the environment replaces cf_token of the user file
+func keepTokenLayer(loaded *LoadedConfig) {
+	keys := []string{"cf_token", "cf_token_file", "token_command"}
+	top := -1
+	for _, key := range keys {
+		if ConfigValue(loaded.Config, key) != "" && sourceRank(loaded.Sources[key]) > top {
+			top = sourceRank(loaded.Sources[key])
+		}
+	}
+	for _, key := range keys {
+		if sourceRank(loaded.Sources[key]) < top {
+			field, _ := configField(&loaded.Config, key)
+			field.SetString("")
+			loaded.Sources[key] = SourceDefault
+		}
+	}
+}
+
+// SetConfigValue sets the config key from its string form.
+// Lists are comma separated and objects are given as JSON.
+func SetConfigValue(config *models.Config, key string, value string) error {
+	field, ok := configField(config, key)
+	if !ok {
+		return fmt.Errorf("unknown config key %q", key)
+	}
+	switch {
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		field.Set(reflect.ValueOf(list))
+	default:
+		ptr := reflect.New(field.Type())
+		if err := json.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+			return fmt.Errorf("%s must be JSON: %v", key, err)
+		}
+		field.Set(ptr.Elem())
+	}
+	return nil
+}
+
+// ConfigValue returns the config key in its string form, secrets are redacted
+func ConfigValue(config models.Config, key string) string {
+	field, ok := configField(&config, key)
+	if !ok {
+		return ""
+	}
+	switch {
+	case field.Kind() == reflect.String:
+		if TypeContains(SecretKeys, key) {
+			return Redact(field.String())
+		}
+		return field.String()
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		return strings.Join(field.Interface().([]string), ",")
+	case key == "zones":
+		// the zones carry their own tokens
+		zones := append([]models.Zone(nil), config.Zones...)
+		for i := range zones {
+			zones[i].CFToken = Redact(zones[i].CFToken)
+		}
+		data, _ := json.Marshal(zones)
+		return string(data)
+	}
+	data, _ := json.Marshal(field.Interface())
+	return string(data)
+}
+
+// Redact hides all but the last characters of a secret
+func Redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 8 {
+		return "********"
+	}
+	return "********" + secret[len(secret)-4:]
+}
+
+// WriteConfig writes the config file, it is only readable by the user as it holds the token
+func WriteConfig(filename string, config models.Config) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0600)
+}
+
+// GenerateConfig writes the config file and a sample records file when the records file does not exist
+func GenerateConfig(filename string, config models.Config) error {
+	if config.RecordFile == "" {
+		config.RecordFile = HomeDir() + "/.mrinjamulcli_dns_records.json"
+		if _, err := os.Stat("records.json"); err == nil {
+			// get current path
+			path, _ := os.Getwd()
+			config.RecordFile = path + "/" + "records.json"
+		}
+	}
+	if len(config.RecordType) == 0 {
+		config.RecordType = []string{"A", "CNAME"}
+	}
+	if config.PrivateIP == "" {
+		config.PrivateIP = "error"
+	}
+	err := WriteConfig(filename, config)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(config.RecordFile); os.IsNotExist(err) {
+		records := []models.Records{
+			{
+				Description: "This is a sample record",
+				Repo:        "",
+				Owner: models.Owner{
+					Username: "username",
+					Email:    "username@domain.com",
+				},
+				Record: models.Record{
+					Type:      "CNAME",
+					Name:      "username",
+					Content:   "username.github.io",
+					Proxiable: true,
+					Proxied:   true,
+					TTL:       1,
+				},
+			},
+		}
+		err = WriteRecords(config.RecordFile, records)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateConfig reports the problems of the config, sources tells where each key comes from
+func ValidateConfig(config models.Config, sources ConfigSources) []Issue {
+	issues := checkTokenCommandSource(sources)
+	var types []string
+	for t := range config.TypeDefaults {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		if !TypeContains(KnownRecordTypes, t) {
+			issues = append(issues, Issue{Level: LevelError, Name: "type_defaults", Message: fmt.Sprintf("unknown record type %q", t)})
+		}
+	}
+	switch config.PrivateIP {
+	case "error", "warn", "warning", "off":
+	default:
+		issues = append(issues, Issue{Level: LevelError, Name: "private_ip", Message: fmt.Sprintf("%q must be error, warn or off", config.PrivateIP)})
+	}
+	if err := SortRecords(nil, config.SortBy); err != nil {
+		issues = append(issues, Issue{Level: LevelError, Name: "sort_by", Message: err.Error()})
+	}
+	if u, err := url.Parse(config.GitHubAPI); err != nil || u.Scheme == "" || u.Host == "" {
+		issues = append(issues, Issue{Level: LevelError, Name: "github_api", Message: fmt.Sprintf("%q is not a valid url", config.GitHubAPI)})
+	}
+	if u, err := url.Parse(config.APIURL); err != nil || u.Scheme == "" || u.Host == "" {
+		issues = append(issues, Issue{Level: LevelError, Name: "api_url", Message: fmt.Sprintf("%q is not a valid url", config.APIURL)})
+	}
+	for _, key := range []string{"ipv4_url", "ipv6_url"} {
+		value := ConfigValue(config, key)
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			issues = append(issues, Issue{Level: LevelError, Name: key, Message: fmt.Sprintf("%q is not a valid url", value)})
+		}
+	}
+	for username := range config.Owners.Overrides {
+		if !ValidUsername(username) {
+			issues = append(issues, Issue{Level: LevelError, Name: "owners", Message: fmt.Sprintf("invalid username %q in overrides", username)})
+		}
+	}
+	if _, err := ResolveZones(config, ZoneSelector{}); err != nil {
+		issues = append(issues, Issue{Level: LevelError, Name: "profile", Message: err.Error()})
+	}
+	zones, err := ResolveZones(config, ZoneSelector{All: true})
+	if err != nil {
+		issues = append(issues, Issue{Level: LevelError, Name: "zones", Message: err.Error()})
+	}
+	secrets := false
+	if filename := SecretsFile(config); filename != "" {
+		if _, err := os.Stat(filename); err != nil {
+			issues = append(issues, Issue{Level: LevelError, Name: "secrets_file", Message: err.Error()})
+		} else {
+			secrets = true
+		}
+	}
+	for _, zone := range zones {
+		issues = append(issues, validateZone(zone, len(config.Zones) > 0, secrets)...)
+	}
+	return issues
+}
+
+// validateZone reports the problems of a single zone
+func validateZone(zone models.Zone, named bool, secrets bool) []Issue {
+	var issues []Issue
+	key := func(name string) string {
+		if named {
+			return "zones." + zone.Name + "." + name
+		}
+		return name
+	}
+	switch {
+	case zone.CFToken != "":
+	case zone.CFTokenFile != "":
+		if _, err := ReadTokenFile(zone.CFTokenFile); err != nil {
+			issues = append(issues, Issue{Level: LevelError, Name: key("cf_token_file"), Message: err.Error()})
+		}
+	case zone.TokenCommand != "":
+	case !secrets:
+		issues = append(issues, Issue{Level: LevelError, Name: key("cf_token"), Message: "is required to talk to cloudflare, set cf_token, cf_token_file, token_command or secrets_file"})
+	}
+	if zone.ZoneID == "" {
+		issues = append(issues, Issue{Level: LevelWarning, Name: key("zone_id"), Message: "is not set, it is looked up from domain_name"})
+	}
+	if _, err := NormalizeName(zone.DomainName); err != nil || zone.DomainName == "@" {
+		issues = append(issues, Issue{Level: LevelError, Name: key("domain_name"), Message: fmt.Sprintf("%q is not a valid domain", zone.DomainName)})
+	}
+	for _, t := range zone.RecordType {
+		if !TypeContains(KnownRecordTypes, t) {
+			issues = append(issues, Issue{Level: LevelError, Name: key("record_type"), Message: fmt.Sprintf("unknown record type %q", t)})
+		}
+	}
+	if _, err := GetRecords(zone.RecordFile); err != nil {
+		issues = append(issues, Issue{Level: LevelError, Name: key("record_file"), Message: err.Error()})
+	}
+	if _, err := LoadRestricted(zone.RestrictedFile); err != nil {
+		level := LevelError
+		if os.IsNotExist(err) {
+			level = LevelWarning
+		}
+		issues = append(issues, Issue{Level: level, Name: key("restricted_file"), Message: err.Error()})
+	}
+	return issues
+}
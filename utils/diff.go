@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around each hunk
+const diffContext = 3
+
+// edit is a single line of an edit script, op is one of ' ', '-' or '+'
+type edit struct {
+	op   byte
+	line string
+}
+
+// splitLines splits the text into lines, each one keeps its line ending so
+// a last line without one differs from the same line with one
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script from a to b using the linear
+// space variant of the Myers algorithm: the common prefix and suffix are kept,
+// the rest is split at the middle snake and each half is compared again
+func diffLines(a []string, b []string) []edit {
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var script []edit
+	for _, line := range a[:prefix] {
+		script = append(script, edit{' ', line})
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if x, y, ok := middleSnake(midA, midB); ok {
+		script = append(script, diffLines(midA[:x], midB[:y])...)
+		script = append(script, diffLines(midA[x:], midB[y:])...)
+	} else {
+		for _, line := range midA {
+			script = append(script, edit{'-', line})
+		}
+		for _, line := range midB {
+			script = append(script, edit{'+', line})
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		script = append(script, edit{' ', line})
+	}
+	return script
+}
+
+// middleSnake searches the shortest edit script from both ends at once and
+// returns where the two searches meet, it is false when a and b share no line
+func middleSnake(a []string, b []string) (int, int, bool) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+	delta := n - m
+	// with an odd delta the forward search meets the backward one
+	odd := delta%2 != 0
+	// the diagonals which left the grid are not searched again
+	var fStart, fEnd, bStart, bEnd int
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[i] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				j := offset + delta - k
+				if j >= 0 && j < len(backward) && backward[j] != -1 && x >= n-backward[j] {
+					return x, y, true
+				}
+			}
+		}
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && backward[i-1] < backward[i+1]) {
+				x = backward[i+1]
+			} else {
+				x = backward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			backward[i] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				j := offset + delta - k
+				if j >= 0 && j < len(forward) && forward[j] != -1 {
+					fx := forward[j]
+					if fx >= n-x {
+						return fx, offset + fx - j, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// UnifiedDiff returns the unified diff between the two texts, empty if they are equal
+func UnifiedDiff(fromName string, toName string, from []byte, to []byte) string {
+	script := diffLines(splitLines(string(from)), splitLines(string(to)))
+
+	// group the changes which are close to each other into hunks
+	var hunks [][2]int
+	for i, e := range script {
+		if e.op == ' ' {
+			continue
+		}
+		start, end := i-diffContext, i+diffContext+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(script) {
+			end = len(script)
+		}
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1][1] {
+			hunks[len(hunks)-1][1] = end
+			continue
+		}
+		hunks = append(hunks, [2]int{start, end})
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+	aLine, bLine, pos := 0, 0, 0
+	for _, hunk := range hunks {
+		// count the lines before the hunk
+		for ; pos < hunk[0]; pos++ {
+			if script[pos].op != '+' {
+				aLine++
+			}
+			if script[pos].op != '-' {
+				bLine++
+			}
+		}
+		var aCount, bCount int
+		for _, e := range script[hunk[0]:hunk[1]] {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+		aStart, bStart := aLine+1, bLine+1
+		if aCount == 0 {
+			aStart = aLine
+		}
+		if bCount == 0 {
+			bStart = bLine
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, e := range script[hunk[0]:hunk[1]] {
+			if strings.HasSuffix(e.line, "\n") {
+				fmt.Fprintf(&b, "%c%s", e.op, e.line)
+			} else {
+				fmt.Fprintf(&b, "%c%s\n\\ No newline at end of file\n", e.op, e.line)
+			}
+		}
+	}
+	return b.String()
+}
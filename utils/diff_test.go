@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"both empty", "", "", ""},
+		{"change", "a\nb\nc\n", "a\nB\nc\n", "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{"from empty", "", "a\n", "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+a\n"},
+		{"to empty", "a\n", "", "--- a\n+++ b\n@@ -1,1 +0,0 @@\n-a\n"},
+		{"newline added", "a\nb", "a\nb\n", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n"},
+		{"newline removed", "a\nb\n", "a\nb", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n"},
+		{"no newline kept", "a\nb", "A\nb", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n-a\n+A\n b\n\\ No newline at end of file\n"},
+	}
+	for _, tt := range tests {
+		if got := UnifiedDiff("a", "b", []byte(tt.from), []byte(tt.to)); got != tt.want {
+			t.Errorf("%s: UnifiedDiff() =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// checkScript checks the script turns a into b with the fewest changes
+func checkScript(t *testing.T, a []string, b []string, script []edit, changes int) {
+	t.Helper()
+	var from, to []string
+	var got int
+	for _, e := range script {
+		if e.op != '+' {
+			from = append(from, e.line)
+		}
+		if e.op != '-' {
+			to = append(to, e.line)
+		}
+		if e.op != ' ' {
+			got++
+		}
+	}
+	if strings.Join(from, "\x00") != strings.Join(a, "\x00") || strings.Join(to, "\x00") != strings.Join(b, "\x00") {
+		t.Fatalf("script does not turn %q into %q", a, b)
+	}
+	if changes >= 0 && got != changes {
+		t.Fatalf("script of %q to %q has %d changes, want %d", a, b, got, changes)
+	}
+}
+
+// lcsChanges returns the fewest changes from a to b from their longest common subsequence
+func lcsChanges(a []string, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] > lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	return len(a) + len(b) - 2*lcs[0][0]
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, r.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + r.Intn(4)))
+		}
+		return lines
+	}
+	for i := 0; i < 2000; i++ {
+		a, b := random(), random()
+		checkScript(t, a, b, diffLines(a, b), lcsChanges(a, b))
+	}
+}
+
+func TestDiffLinesLargeReorder(t *testing.T) {
+	const n = 3000
+	a := make([]string, n)
+	b := make([]string, n)
+	for i := range a {
+		a[i] = fmt.Sprintf("    \"name\": \"sub%d\",", i)
+		b[n-1-i] = a[i]
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	script := diffLines(a, b)
+	runtime.ReadMemStats(&after)
+	// the reverse keeps a single line
+	checkScript(t, a, b, script, 2*n-2)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 64<<20 {
+		t.Errorf("diff of %d reversed lines allocated %d MB", n, allocated>>20)
+	}
+}
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// FilterKeys are the fields a filter can match
+var FilterKeys = []string{"name", "type", "owner", "proxied", "content"}
+
+// filterTerm is a single `key=value` condition of a filter
+type filterTerm struct {
+	key    string
+	negate bool
+	re     *regexp.Regexp
+	// proxied is the value of a proxied term
+	proxied bool
+}
+
+// Filter selects records, all of its terms must match
+type Filter struct {
+	terms []filterTerm
+}
+
+// Filters selects the records matching any of the filters, no filter selects every record
+type Filters []Filter
+
+// termStart matches the comma starting a new term, the other commas belong
+// to the value e.g. `content~a{1,3}`
+var termStart = regexp.MustCompile(`,\s*[A-Za-z]\w*\s*!?[=~]`)
+
+// splitTerms splits the filter at the commas starting a new term
+func splitTerms(expr string) []string {
+	var parts []string
+	start := 0
+	for _, loc := range termStart.FindAllStringIndex(expr, -1) {
+		parts = append(parts, expr[start:loc[0]])
+		start = loc[0] + 1
+	}
+	return append(parts, expr[start:])
+}
+
+// ParseFilter parses a comma separated list of terms e.g. `name=blog*,owner=alice`.
+// `key=value` matches a glob with `|` between alternatives, `key~value` searches
+// a regular expression e.g. `content~github.io`, `!=` and `!~` negate them.
+// A value may hold commas unless they are followed by another term.
+// Matching ignores the case.
+func ParseFilter(expr string) (Filter, error) {
+	var filter Filter
+	for _, part := range splitTerms(expr) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		i := strings.IndexAny(part, "=~")
+		if i <= 0 {
+			return filter, fmt.Errorf("invalid filter %q, use key=value or key~regex", part)
+		}
+		term := filterTerm{key: strings.ToLower(strings.TrimSpace(part[:i]))}
+		op, value := part[i:i+1], strings.TrimSpace(part[i+1:])
+		if strings.HasSuffix(term.key, "!") {
+			term.key, term.negate = strings.TrimSpace(strings.TrimSuffix(term.key, "!")), true
+		}
+		if !TypeContains(FilterKeys, term.key) {
+			return filter, fmt.Errorf("unknown filter key %q (use %s)", term.key, strings.Join(FilterKeys, ", "))
+		}
+		if term.key == "proxied" {
+			proxied, err := strconv.ParseBool(value)
+			if err != nil || op != "=" {
+				return filter, fmt.Errorf("invalid filter %q, use proxied=true or proxied=false", part)
+			}
+			term.proxied = proxied
+			filter.terms = append(filter.terms, term)
+			continue
+		}
+		expr := value
+		if op == "=" {
+			var alternatives []string
+			for _, alternative := range strings.Split(value, "|") {
+				alternatives = append(alternatives, globToRegex(alternative))
+			}
+			expr = "^(?:" + strings.Join(alternatives, "|") + ")$"
+		}
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return filter, fmt.Errorf("invalid filter %q: %v", part, err)
+		}
+		term.re = re
+		filter.terms = append(filter.terms, term)
+	}
+	return filter, nil
+}
+
+// ParseFilters parses every filter expression
+func ParseFilters(exprs []string) (Filters, error) {
+	var filters Filters
+	for _, expr := range exprs {
+		filter, err := ParseFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// matches checks the term against the entry, name is relative to the domain
+func (t filterTerm) matches(entry models.Records, name string) bool {
+	var ok bool
+	switch t.key {
+	case "name":
+		ok = t.re.MatchString(name)
+	case "type":
+		ok = t.re.MatchString(entry.Record.Type)
+	case "owner":
+		ok = t.re.MatchString(entry.Owner.Username) || t.re.MatchString(entry.Owner.Email)
+	case "content":
+		ok = t.re.MatchString(entry.Record.Content)
+	case "proxied":
+		ok = entry.Record.Proxied == t.proxied
+	}
+	return ok != t.negate
+}
+
+// Match checks if the entry matches every term of the filter,
+// the name of the entry may be relative or fully qualified
+func (f Filter) Match(entry models.Records, domain string) bool {
+	name := TrimDomain(entry.Record.Name, domain)
+	for _, term := range f.terms {
+		if !term.matches(entry, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match checks if the entry matches any of the filters
+func (fs Filters) Match(entry models.Records, domain string) bool {
+	if len(fs) == 0 {
+		return true
+	}
+	for _, f := range fs {
+		if f.Match(entry, domain) {
+			return true
+		}
+	}
+	return false
+}
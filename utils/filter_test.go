@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+func TestSplitTerms(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"name=blog", []string{"name=blog"}},
+		{"name=blog*,owner=alice", []string{"name=blog*", "owner=alice"}},
+		{"name=blog*, owner != alice ,type~^A", []string{"name=blog*", " owner != alice ", "type~^A"}},
+		{"content~a{1,3}", []string{"content~a{1,3}"}},
+		{"content~a{1,3},name!~^x", []string{"content~a{1,3}", "name!~^x"}},
+		{"content~^(a|b),c$,proxied=true", []string{"content~^(a|b),c$", "proxied=true"}},
+		{"name=blog,", []string{"name=blog,"}},
+	}
+	for _, tt := range tests {
+		got := splitTerms(tt.expr)
+		if len(got) != len(tt.want) {
+			t.Errorf("splitTerms(%q) = %q, want %q", tt.expr, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("splitTerms(%q) = %q, want %q", tt.expr, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	entries := map[string]models.Records{
+		"blog": {Owner: models.Owner{Username: "alice"}, Record: models.Record{Name: "blog", Type: "CNAME", Content: "alice.github.io", Proxied: true}},
+		"aaa":  {Owner: models.Owner{Username: "bob"}, Record: models.Record{Name: "aaa.example.com", Type: "A", Content: "aaa"}},
+		"x":    {Owner: models.Owner{Username: "bob"}, Record: models.Record{Name: "x", Type: "A", Content: "a"}},
+	}
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"name=blog", []string{"blog"}},
+		{"owner=bob,type=a", []string{"aaa", "x"}},
+		{"content~^a{2,3}$", []string{"aaa"}},
+		{"content~^a{2,3}$, owner=bob", []string{"aaa"}},
+		{"owner!=alice,content!~^a{2,3}$", []string{"x"}},
+		{"name=aaa|x,proxied=false", []string{"aaa", "x"}},
+	}
+	for _, tt := range tests {
+		filter, err := ParseFilter(tt.expr)
+		if err != nil {
+			t.Errorf("ParseFilter(%q) = %v", tt.expr, err)
+			continue
+		}
+		var got []string
+		for _, name := range []string{"aaa", "blog", "x"} {
+			if filter.Match(entries[name], "example.com") {
+				got = append(got, name)
+			}
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) || (len(got) > 1 && got[1] != tt.want[1]) {
+			t.Errorf("ParseFilter(%q) selects %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+	for _, expr := range []string{"blog", "color=red", "proxied~true", "content~a(1,3", "name=blog,colour=red"} {
+		if _, err := ParseFilter(expr); err == nil {
+			t.Errorf("ParseFilter(%q) succeeded, want an error", expr)
+		}
+	}
+}
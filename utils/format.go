@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// Sort orders for the records file
+const (
+	SortByName  = "name"
+	SortByType  = "type"
+	SortByOwner = "owner"
+	SortByNone  = "none"
+)
+
+// CanonicalName returns the name relative to the domain in normalised form
+// e.g. `API.Example.com.` becomes `api` for the domain example.com
+func CanonicalName(name string, domain string) (string, error) {
+	return NormalizeName(TrimDomain(strings.TrimSpace(name), domain))
+}
+
+// FQDN returns the fully qualified name of the relative name, `@` is the domain itself
+func FQDN(name string, domain string) string {
+	if name == "@" || name == "" {
+		return domain
+	}
+	return name + "." + domain
+}
+
+// SortRecords sorts the records in place, records which compares equal keeps their order
+func SortRecords(records []models.Records, by string) error {
+	var less func(a, b models.Record, ao, bo models.Owner) bool
+	switch by {
+	case "", SortByName:
+		less = func(a, b models.Record, _, _ models.Owner) bool {
+			if a.Name != b.Name {
+				return nameLess(a.Name, b.Name)
+			}
+			if a.Type != b.Type {
+				return a.Type < b.Type
+			}
+			return a.Content < b.Content
+		}
+	case SortByType:
+		less = func(a, b models.Record, _, _ models.Owner) bool {
+			if a.Type != b.Type {
+				return a.Type < b.Type
+			}
+			return nameLess(a.Name, b.Name)
+		}
+	case SortByOwner:
+		less = func(a, b models.Record, ao, bo models.Owner) bool {
+			au, bu := strings.ToLower(ao.Username), strings.ToLower(bo.Username)
+			if au != bu {
+				return au < bu
+			}
+			return nameLess(a.Name, b.Name)
+		}
+	case SortByNone:
+		return nil
+	default:
+		return fmt.Errorf("unknown sort %q (use name, type, owner or none)", by)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return less(records[i].Record, records[j].Record, records[i].Owner, records[j].Owner)
+	})
+	return nil
+}
+
+// nameLess orders the root first and the names by their labels from the right
+// so subdomains are listed next to their parent
+func nameLess(a string, b string) bool {
+	if a == "@" || b == "@" {
+		return a == "@" && b != "@"
+	}
+	al, bl := strings.Split(a, "."), strings.Split(b, ".")
+	for i, j := len(al)-1, len(bl)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if al[i] != bl[j] {
+			return al[i] < bl[j]
+		}
+	}
+	return len(al) < len(bl)
+}
+
+// RemoveRecords returns the records without the given indexes, keeping the order of the rest
+func RemoveRecords(records []models.Records, indexes []int) []models.Records {
+	remove := make(map[int]bool)
+	for _, i := range indexes {
+		remove[i] = true
+	}
+	kept := make([]models.Records, 0, len(records))
+	for i, record := range records {
+		if !remove[i] {
+			kept = append(kept, record)
+		}
+	}
+	return kept
+}
+
+// MarshalRecords returns the canonical layout of the records file
+func MarshalRecords(records []models.Records) ([]byte, error) {
+	if records == nil {
+		records = []models.Records{}
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteRecords writes the records to the file in the canonical layout
+func WriteRecords(filename string, records []models.Records) error {
+	data, err := MarshalRecords(records)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// formatRecords runs the steps of fmt on the content of a records file
+func formatRecords(t *testing.T, data []byte, domain string) []byte {
+	t.Helper()
+	records, err := ParseRecords(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range records {
+		name, err := CanonicalName(records[i].Record.Name, domain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records[i].Record.Name = name
+		ApplyTypeDefaults(&records[i], nil)
+	}
+	if err := SortRecords(records, SortByName); err != nil {
+		t.Fatal(err)
+	}
+	out, err := MarshalRecords(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestFormatIsIdempotent(t *testing.T) {
+	input := []byte(`[
+{"record":{"type":"TXT","name":"_dmarc.Example.com.","content":"v=DMARC1","proxied":true}},
+{"owner":{"username":"bob"},"record":{"name":"x.blog","type":"A","content":"1.2.3.4"}},
+{"record":{"name":"BLOG.example.com","type":"CNAME","content":"alice.github.io"},"owner":{"username":"alice"}},
+{"dns_only":true,"record":{"name":"Bücher","type":"A","content":"5.6.7.8","proxied":true}},
+{"record":{"name":"@","type":"A","content":"9.9.9.9","ttl":300}}
+]`)
+	once := formatRecords(t, input, "example.com")
+	twice := formatRecords(t, once, "example.com")
+	if !bytes.Equal(once, twice) {
+		t.Errorf("fmt is not idempotent:\n%s\nthen\n%s", once, twice)
+	}
+
+	records, _ := ParseRecords(once)
+	var names []string
+	for _, entry := range records {
+		names = append(names, entry.Record.Name)
+	}
+	want := []string{"@", "_dmarc", "blog", "x.blog", "xn--bcher-kva"}
+	if len(names) != len(want) {
+		t.Fatalf("names = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("names = %v, want %v", names, want)
+		}
+	}
+}
+
+func TestRemoveRecords(t *testing.T) {
+	var records []models.Records
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		records = append(records, models.Records{Record: models.Record{Name: name}})
+	}
+	tests := []struct {
+		indexes []int
+		want    string
+	}{
+		{nil, "abcdef"},
+		{[]int{0}, "bcdef"},
+		{[]int{5}, "abcde"},
+		// removing one entry used to move the last one into its place,
+		// so the next index pointed at the wrong entry
+		{[]int{1, 3}, "acef"},
+		{[]int{3, 1}, "acef"},
+		{[]int{0, 1, 5}, "cde"},
+		{[]int{2, 2}, "abdef"},
+		{[]int{0, 1, 2, 3, 4, 5}, ""},
+	}
+	for _, tt := range tests {
+		var got string
+		for _, entry := range RemoveRecords(records, tt.indexes) {
+			got += entry.Record.Name
+		}
+		if got != tt.want {
+			t.Errorf("RemoveRecords(%v) = %q, want %q", tt.indexes, got, tt.want)
+		}
+	}
+	if len(records) != 6 || records[0].Record.Name != "a" || records[5].Record.Name != "f" {
+		t.Errorf("RemoveRecords changed its input: %v", records)
+	}
+}
+
+func TestCanonicalName(t *testing.T) {
+	tests := []struct {
+		name   string
+		domain string
+		want   string
+	}{
+		{"blog", "example.com", "blog"},
+		{"blog.", "example.com", "blog"},
+		{"Blog.Example.com.", "example.com", "blog"},
+		{"blog.example.com", "example.com", "blog"},
+		{"x.blog.example.com", "example.com", "x.blog"},
+		{"example.com", "example.com", "@"},
+		{"example.com.", "example.com", "@"},
+		{"@", "example.com", "@"},
+		{" blog ", "example.com", "blog"},
+		// only a whole label suffix is the domain
+		{"myexample.com", "example.com", "myexample.com"},
+		{"blog.example.com", "", "blog.example.com"},
+	}
+	for _, tt := range tests {
+		got, err := CanonicalName(tt.name, tt.domain)
+		if err != nil || got != tt.want {
+			t.Errorf("CanonicalName(%q, %q) = %q, %v, want %q", tt.name, tt.domain, got, err, tt.want)
+		}
+	}
+	for _, name := range []string{"", ".", "bad name"} {
+		if got, err := CanonicalName(name, "example.com"); err == nil {
+			t.Errorf("CanonicalName(%q) = %q, want an error", name, got)
+		}
+	}
+}
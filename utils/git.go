@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// GitShow returns the content of the file at the git ref,
+// the error wraps os.ErrNotExist when the file is not in the ref
+func GitShow(ref string, filename string) ([]byte, error) {
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", dir, "show", ref+":./"+base)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if strings.Contains(message, "does not exist") || strings.Contains(message, "but not in") {
+			return nil, fmt.Errorf("%s is not in %s: %w", filename, ref, os.ErrNotExist)
+		}
+		if message == "" {
+			message = err.Error()
+		}
+		return nil, fmt.Errorf("git show %s:%s: %s", ref, filename, message)
+	}
+	return stdout.Bytes(), nil
+}
+
+// GitRecords loads the records file as it is at the git ref
+func GitRecords(ref string, filename string) ([]models.Records, error) {
+	data, err := GitShow(ref, filename)
+	if err != nil {
+		return []models.Records{}, err
+	}
+	records, err := ParseRecords(data)
+	if err != nil {
+		return []models.Records{}, fmt.Errorf("%s at %s: %v", filename, ref, err)
+	}
+	return records, nil
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitRepo creates a repository with a commit adding the records file, the
+// commit tagged `empty` comes before it
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "empty")
+	git("tag", "empty")
+	records := `[{"record":{"name":"blog","type":"CNAME","content":"alice.github.io"}}]`
+	if err := os.WriteFile(filepath.Join(dir, "records.json"), []byte(records), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`[{`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "records")
+	// the working copy differs from the commit
+	if err := os.WriteFile(filepath.Join(dir, "records.json"), []byte(`[]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestGitRecords(t *testing.T) {
+	dir := gitRepo(t)
+	filename := filepath.Join(dir, "records.json")
+
+	records, err := GitRecords("HEAD", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Record.Name != "blog" {
+		t.Errorf("GitRecords(HEAD) = %+v", records)
+	}
+
+	records, err = GitRecords("empty", filename)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("GitRecords(empty) = %v, want a not exist error", err)
+	}
+	if records == nil || len(records) != 0 {
+		t.Errorf("GitRecords(empty) = %+v, want no records", records)
+	}
+
+	if _, err := GitRecords("no-such-ref", filename); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("GitRecords(no-such-ref) = %v, want a git error", err)
+	}
+	if _, err := GitRecords("HEAD", filepath.Join(dir, "broken.json")); err == nil || !strings.Contains(err.Error(), "at HEAD") {
+		t.Errorf("GitRecords(broken) = %v, want a parse error", err)
+	}
+}
+
+func TestGitShow(t *testing.T) {
+	dir := gitRepo(t)
+	data, err := GitShow("HEAD", filepath.Join(dir, "records.json"))
+	if err != nil || !strings.Contains(string(data), "alice.github.io") {
+		t.Errorf("GitShow(HEAD) = %q, %v", data, err)
+	}
+	if _, err := GitShow("HEAD", filepath.Join(dir, "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("GitShow(missing) = %v, want a not exist error", err)
+	}
+}
@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// GitHubAPI is the default base url for the GitHub api
+const GitHubAPI = "https://api.github.com"
+
+// GitHost looks up users and repositories on a Git hosting service
+type GitHost interface {
+	UserExists(username string) (bool, error)
+	RepoExists(owner string, repo string) (bool, error)
+}
+
+// GitHubClient is the GitHost backed by the GitHub REST api
+type GitHubClient struct {
+	BaseURL string
+	Token   string
+	Client  *http.Client
+}
+
+// NewGitHubClient returns a client for the api at baseURL
+func NewGitHubClient(baseURL string, token string) *GitHubClient {
+	if baseURL == "" {
+		baseURL = GitHubAPI
+	}
+	return &GitHubClient{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// exists checks if the api path returns 200 OK
+func (c *GitHubClient) exists(path string) (bool, error) {
+	req, err := http.NewRequest("GET", c.BaseURL+path, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Add("Accept", "application/vnd.github+json")
+	if c.Token != "" {
+		req.Header.Add("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status %s from %s", resp.Status, path)
+	}
+}
+
+// UserExists checks if the user exists
+func (c *GitHubClient) UserExists(username string) (bool, error) {
+	return c.exists("/users/" + url.PathEscape(username))
+}
+
+// RepoExists checks if the repository exists
+func (c *GitHubClient) RepoExists(owner string, repo string) (bool, error) {
+	return c.exists("/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo))
+}
+
+// ParseRepoURL splits a repository url e.g. https://github.com/owner/repo
+func ParseRepoURL(repo string) (host string, owner string, name string, err error) {
+	u, err := url.Parse(repo)
+	if err != nil {
+		return "", "", "", err
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return "", "", "", fmt.Errorf("repo must be an https url")
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("repo must look like https://%s/owner/repo", u.Host)
+	}
+	owner, name = parts[0], strings.TrimSuffix(parts[1], ".git")
+	if !ValidUsername(owner) {
+		return "", "", "", fmt.Errorf("repo owner %q is invalid", owner)
+	}
+	return strings.ToLower(u.Host), owner, name, nil
+}
+
+// lookup is the cached answer of the GitHost
+type lookup struct {
+	ok  bool
+	err error
+}
+
+// VerifyClaims checks the repo and owner of every entry.
+// Users and repositories on github.com are looked up using the host when it is not nil,
+// each one is looked up once as an owner usually has several entries.
+func VerifyClaims(records []models.Records, host GitHost, domain string) []Issue {
+	var issues []Issue
+	users := make(map[string]lookup)
+	userExists := func(username string) (bool, error) {
+		key := strings.ToLower(username)
+		if cached, ok := users[key]; ok {
+			return cached.ok, cached.err
+		}
+		ok, err := host.UserExists(username)
+		users[key] = lookup{ok, err}
+		return ok, err
+	}
+	repos := make(map[string]lookup)
+	repoExists := func(owner string, repo string) (bool, error) {
+		key := strings.ToLower(owner + "/" + repo)
+		if cached, ok := repos[key]; ok {
+			return cached.ok, cached.err
+		}
+		ok, err := host.RepoExists(owner, repo)
+		repos[key] = lookup{ok, err}
+		return ok, err
+	}
+	for _, entry := range records {
+		name := TrimDomain(entry.Record.Name, domain)
+		username := entry.Owner.Username
+
+		if username != "" && host != nil {
+			ok, err := userExists(username)
+			if err != nil {
+				issues = append(issues, Issue{Level: LevelWarning, Name: name, Message: fmt.Sprintf("cannot verify owner %s: %v", username, err), Rule: RuleVerifyOwner})
+			} else if !ok {
+				issues = append(issues, Issue{Level: LevelError, Name: name, Message: fmt.Sprintf("owner %s does not exist", username), Rule: RuleVerifyOwner})
+			}
+		}
+
+		// the organisation owning the repo may host the site on its own pages
+		var repoOrg string
+		if entry.Repo != "" {
+			repoHost, repoOwner, repoName, err := ParseRepoURL(entry.Repo)
+			if err != nil {
+				issues = append(issues, Issue{Level: LevelError, Name: name, Message: fmt.Sprintf("repo %s: %v", entry.Repo, err), Rule: RuleVerifyRepo})
+			} else {
+				if username != "" && !strings.EqualFold(repoOwner, username) {
+					issues = append(issues, Issue{Level: LevelWarning, Name: name, Message: fmt.Sprintf("repo belongs to %s, not to the owner %s", repoOwner, username), Rule: RuleVerifyRepoOf})
+				}
+				if repoHost == "github.com" {
+					repoOrg = strings.ToLower(repoOwner)
+				}
+				if repoHost == "github.com" && host != nil {
+					ok, err := repoExists(repoOwner, repoName)
+					if err != nil {
+						issues = append(issues, Issue{Level: LevelWarning, Name: name, Message: fmt.Sprintf("cannot verify repo %s: %v", entry.Repo, err), Rule: RuleVerifyRepo})
+					} else if !ok {
+						issues = append(issues, Issue{Level: LevelError, Name: name, Message: fmt.Sprintf("repo %s does not exist", entry.Repo), Rule: RuleVerifyRepo})
+					}
+				}
+			}
+		}
+
+		// GitHub Pages targets must belong to the owner or to the owner of the repo
+		target := strings.ToLower(strings.TrimSuffix(entry.Record.Content, "."))
+		if entry.Record.Type == "CNAME" && strings.HasSuffix(target, ".github.io") {
+			expected := strings.ToLower(username) + ".github.io"
+			if target != expected && (repoOrg == "" || target != repoOrg+".github.io") {
+				message := fmt.Sprintf("CNAME target %s does not match the owner, expected %s", entry.Record.Content, expected)
+				if repoOrg != "" && repoOrg != strings.ToLower(username) {
+					message += " or " + repoOrg + ".github.io"
+				} else {
+					message += ", set repo to a repository of the organisation for its pages"
+				}
+				issues = append(issues, Issue{Level: LevelError, Name: name, Message: message, Rule: RuleVerifyPages})
+			}
+		}
+	}
+	return issues
+}
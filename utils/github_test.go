@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// fakeGitHub serves the users and repositories of the GitHub api and counts the requests
+func fakeGitHub(t *testing.T, requests map[string]int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/users/alice", "/users/bob", "/repos/alice/blog", "/repos/acme/site":
+			w.Write([]byte(`{}`))
+		case "/users/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGitHubClient(t *testing.T) {
+	requests := make(map[string]int)
+	client := NewGitHubClient(fakeGitHub(t, requests).URL+"/", "secret")
+
+	if ok, err := client.UserExists("alice"); !ok || err != nil {
+		t.Errorf("UserExists(alice) = %t, %v, want true", ok, err)
+	}
+	if ok, err := client.UserExists("nobody"); ok || err != nil {
+		t.Errorf("UserExists(nobody) = %t, %v, want false", ok, err)
+	}
+	if _, err := client.UserExists("broken"); err == nil {
+		t.Error("UserExists(broken) succeeded, want an error")
+	}
+	if ok, err := client.RepoExists("alice", "blog"); !ok || err != nil {
+		t.Errorf("RepoExists(alice, blog) = %t, %v, want true", ok, err)
+	}
+	if ok, err := client.RepoExists("alice", "gone"); ok || err != nil {
+		t.Errorf("RepoExists(alice, gone) = %t, %v, want false", ok, err)
+	}
+	anonymous := NewGitHubClient(client.BaseURL, "")
+	if _, err := anonymous.UserExists("alice"); err == nil {
+		t.Error("UserExists() without the token succeeded, want an error")
+	}
+}
+
+func TestVerifyClaims(t *testing.T) {
+	requests := make(map[string]int)
+	client := NewGitHubClient(fakeGitHub(t, requests).URL, "secret")
+	records := []models.Records{
+		{Owner: models.Owner{Username: "alice"}, Repo: "https://github.com/alice/blog", Record: models.Record{Type: "CNAME", Name: "blog", Content: "alice.github.io"}},
+		{Owner: models.Owner{Username: "Alice"}, Repo: "https://github.com/alice/blog", Record: models.Record{Type: "CNAME", Name: "www.blog", Content: "alice.github.io"}},
+		{Owner: models.Owner{Username: "alice"}, Record: models.Record{Type: "CNAME", Name: "docs", Content: "bob.github.io"}},
+		{Owner: models.Owner{Username: "bob"}, Repo: "https://github.com/alice/gone", Record: models.Record{Type: "A", Name: "app", Content: "1.2.3.4"}},
+		{Owner: models.Owner{Username: "nobody"}, Record: models.Record{Type: "A", Name: "x", Content: "1.2.3.4"}},
+		{Owner: models.Owner{Username: "broken"}, Record: models.Record{Type: "A", Name: "y", Content: "1.2.3.4"}},
+		// the pages of an organisation are claimed by a member with a repo of the organisation
+		{Owner: models.Owner{Username: "bob"}, Repo: "https://github.com/acme/site", Record: models.Record{Type: "CNAME", Name: "acme", Content: "acme.github.io"}},
+		{Owner: models.Owner{Username: "bob"}, Record: models.Record{Type: "CNAME", Name: "other", Content: "acme.github.io"}},
+	}
+	issues := VerifyClaims(records, client, "example.com")
+	want := []Issue{
+		{Level: LevelError, Name: "docs", Rule: RuleVerifyPages},
+		{Level: LevelWarning, Name: "app", Rule: RuleVerifyRepoOf},
+		{Level: LevelError, Name: "app", Rule: RuleVerifyRepo},
+		{Level: LevelError, Name: "x", Rule: RuleVerifyOwner},
+		{Level: LevelWarning, Name: "y", Rule: RuleVerifyOwner},
+		{Level: LevelWarning, Name: "acme", Rule: RuleVerifyRepoOf},
+		{Level: LevelError, Name: "other", Rule: RuleVerifyPages},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("VerifyClaims() = %v, want %d issues", issues, len(want))
+	}
+	for i := range want {
+		if issues[i].Level != want[i].Level || issues[i].Name != want[i].Name || issues[i].Rule != want[i].Rule {
+			t.Errorf("issue %d = %v (%s), want %s on %s (%s)", i, issues[i], issues[i].Rule, want[i].Level, want[i].Name, want[i].Rule)
+		}
+	}
+	for path, count := range requests {
+		if count != 1 {
+			t.Errorf("%s requested %d times, want once", path, count)
+		}
+	}
+}
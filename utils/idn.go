@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+	"golang.org/x/net/idna"
+)
+
+// acePrefix starts the ascii form of a unicode label
+const acePrefix = "xn--"
+
+// idnaProfile converts labels with the UTS #46 lookup mapping,
+// e.g. NFC normalization, case folding and removal of zero width characters
+var idnaProfile = idna.New(idna.MapForLookup(), idna.Transitional(false))
+
+// labelSeparators are the dots which separate labels besides the ascii one
+var labelSeparators = strings.NewReplacer("\u3002", ".", "\uff0e", ".", "\uff61", ".")
+
+// validLabel checks the characters of an ascii label
+func validLabel(label string) bool {
+	if label == "*" {
+		return true
+	}
+	if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+		return false
+	}
+	for _, c := range label {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeName lowercases the name, strips the trailing dot and converts
+// unicode labels to punycode e.g. `Bücher.` becomes `xn--bcher-kva`
+func NormalizeName(name string) (string, error) {
+	name = strings.TrimSuffix(strings.TrimSpace(name), ".")
+	if name == "@" {
+		return name, nil
+	}
+	if name == "" {
+		return "", fmt.Errorf("name cannot be empty")
+	}
+	labels := strings.Split(strings.ToLower(labelSeparators.Replace(name)), ".")
+	for i, label := range labels {
+		if !isASCII(label) || strings.HasPrefix(label, acePrefix) {
+			ascii, err := idnaProfile.ToASCII(label)
+			if err != nil {
+				return "", fmt.Errorf("invalid label %q in %q: %v", labels[i], name, err)
+			}
+			label = ascii
+		}
+		if !validLabel(label) {
+			return "", fmt.Errorf("invalid label %q in %q", labels[i], name)
+		}
+		labels[i] = label
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// isASCII checks if the text only has ascii characters
+func isASCII(text string) bool {
+	for _, r := range text {
+		if r >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// ToUnicode converts the punycode labels of the name to unicode
+func ToUnicode(name string) string {
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		if strings.HasPrefix(strings.ToLower(label), acePrefix) {
+			if decoded, err := idnaProfile.ToUnicode(label); err == nil {
+				labels[i] = decoded
+			}
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// confusables maps look-alike characters to the ascii character they imitate,
+// `1` is handled separately as it reads as both `l` and `i`
+var confusables = map[rune]string{
+	// digits
+	'0': "o", '3': "e", '5': "s",
+	// latin
+	'ı': "i", 'ł': "l", 'ß': "ss",
+	// cyrillic
+	'а': "a", 'в': "b", 'е': "e", 'ё': "e", 'һ': "h", 'і': "i", 'ї': "i", 'ј': "j",
+	'к': "k", 'м': "m", 'н': "h", 'о': "o", 'р': "p", 'с': "c", 'т': "t", 'у': "y",
+	'х': "x", 'ѕ': "s", 'ԁ': "d", 'ԛ': "q", 'ԝ': "w", 'ь': "b",
+	// greek
+	'α': "a", 'β': "b", 'ε': "e", 'η': "n", 'ι': "i", 'κ': "k", 'ν': "v", 'ο': "o",
+	'ρ': "p", 'τ': "t", 'υ': "u", 'χ': "x", 'ω': "w",
+}
+
+// foldConfusables replaces look-alike characters with the ascii character they imitate
+func foldConfusables(name string, one string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(ToUnicode(name)) {
+		if r == '1' {
+			b.WriteString(one)
+			continue
+		}
+		if s, ok := confusables[r]; ok {
+			b.WriteString(s)
+			continue
+		}
+		// drop combining marks e.g. the accent of é
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Lookalikes returns the plain ascii names the name can be read as e.g. `paypa1` reads as `paypal`
+func Lookalikes(name string) []string {
+	var names []string
+	for _, one := range []string{"l", "i"} {
+		folded := foldConfusables(name, one)
+		for _, n := range []string{folded, strings.ReplaceAll(folded, "rn", "m")} {
+			if n != name && !TypeContains(names, n) {
+				names = append(names, n)
+			}
+		}
+	}
+	return names
+}
+
+// Skeleton returns the form of the name used to detect look-alike names,
+// two names with the same skeleton are confusable
+func Skeleton(name string) string {
+	skeleton := foldConfusables(name, "l")
+	skeleton = strings.ReplaceAll(skeleton, "i", "l")
+	skeleton = strings.ReplaceAll(skeleton, "rn", "m")
+	skeleton = strings.ReplaceAll(skeleton, "vv", "w")
+	return skeleton
+}
+
+// ConfusableLevel returns how serious it is that two names look alike. Plain ascii
+// names like `mail` and `mall` are told apart by reading them, so they are only a warning,
+// a name with unicode characters can be made to look exactly like the other one.
+func ConfusableLevel(name string, other string) string {
+	if isASCII(ToUnicode(name)) && isASCII(ToUnicode(other)) {
+		return LevelWarning
+	}
+	return LevelError
+}
+
+// NormalizeRecords normalizes the names of the records and reports the invalid ones
+func NormalizeRecords(records []models.Records) []Issue {
+	var issues []Issue
+	for i := range records {
+		name, err := NormalizeName(records[i].Record.Name)
+		if err != nil {
+			issues = append(issues, Issue{Level: LevelError, Name: records[i].Record.Name, Message: err.Error(), Rule: RuleName})
+			continue
+		}
+		records[i].Record.Name = name
+	}
+	return issues
+}
+
+// CheckConfusables reports names which look like a restricted name or another record,
+// see ConfusableLevel for the level of the issues
+func CheckConfusables(records []models.Records, restricted *RestrictedList, domain string) []Issue {
+	var issues []Issue
+	// first name seen for every skeleton
+	seen := make(map[string]string)
+	var names []string
+	for _, entry := range records {
+		name := TrimDomain(entry.Record.Name, domain)
+		if !TypeContains(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !restricted.IsRestricted(name) {
+			for _, lookalike := range Lookalikes(name) {
+				if rule, ok := restricted.Match(lookalike); ok {
+					issues = append(issues, Issue{
+						Level:   ConfusableLevel(name, name),
+						Name:    name,
+						Message: fmt.Sprintf("looks like a restricted name (%s reads as %s, matched %s)", ToUnicode(name), lookalike, rule),
+						Rule:    RuleLookalike,
+					})
+					break
+				}
+			}
+		}
+		skeleton := Skeleton(name)
+		if other, ok := seen[skeleton]; ok {
+			issues = append(issues, Issue{
+				Level:   ConfusableLevel(name, other),
+				Name:    name,
+				Message: fmt.Sprintf("is confusable with %s", other),
+				Rule:    RuleConfusable,
+			})
+			continue
+		}
+		seen[skeleton] = name
+	}
+	return issues
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Bücher.", "xn--bcher-kva"},
+		{"münchen", "xn--mnchen-3ya"},
+		{"MÜNCHEN.blog", "xn--mnchen-3ya.blog"},
+		// decomposed ü is normalized to NFC first
+		{"mu\u0308nchen", "xn--mnchen-3ya"},
+		// fullwidth letters and dots are mapped by UTS #46
+		{"ｂｌｏｇ．ｄｅｖ", "blog.dev"},
+		{"faß", "xn--fa-hia"},
+		{"☃", "xn--n3h"},
+		{"аpi", "xn--pi-6kc"},
+		{"xn--bcher-kva", "xn--bcher-kva"},
+		{"_dmarc", "_dmarc"},
+		{"*.dev", "*.dev"},
+		{"@", "@"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeName(tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeName(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	for _, name := range []string{"", "xn--zz", "-blog", "blog-", "a b", "a..b"} {
+		if got, err := NormalizeName(name); err == nil {
+			t.Errorf("NormalizeName(%q) = %q, want an error", name, got)
+		}
+	}
+}
+
+func TestToUnicode(t *testing.T) {
+	tests := map[string]string{
+		"xn--bcher-kva":      "bücher",
+		"xn--mnchen-3ya.dev": "münchen.dev",
+		"xn--pi-6kc":         "аpi",
+		"blog":               "blog",
+		"xn--zz":             "xn--zz",
+	}
+	for name, want := range tests {
+		if got := ToUnicode(name); got != want {
+			t.Errorf("ToUnicode(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCheckConfusables(t *testing.T) {
+	restricted := &RestrictedList{Rules: []RestrictedRule{{Pattern: "api"}, {Pattern: "paypal"}}}
+	for i := range restricted.Rules {
+		if err := restricted.Rules[i].compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		names []string
+		level string
+	}{
+		// plain ascii names which differ are only a warning
+		{[]string{"mail", "mall"}, LevelWarning},
+		{[]string{"bill", "bi11"}, LevelWarning},
+		{[]string{"s3", "se"}, LevelWarning},
+		{[]string{"paypa1"}, LevelWarning},
+		// unicode look-alikes are errors
+		{[]string{"xn--pi-6kc"}, LevelError},
+		{[]string{"m\u0430il", "mail"}, LevelError},
+		// distinct names
+		{[]string{"blog", "docs"}, ""},
+	}
+	for _, tt := range tests {
+		var records []models.Records
+		for _, name := range tt.names {
+			records = append(records, models.Records{Record: models.Record{Name: name}})
+		}
+		if issues := NormalizeRecords(records); len(issues) > 0 {
+			t.Fatal(issues)
+		}
+		issues := CheckConfusables(records, restricted, "")
+		if tt.level == "" {
+			if len(issues) > 0 {
+				t.Errorf("CheckConfusables(%v) = %v, want none", tt.names, issues)
+			}
+			continue
+		}
+		if len(issues) != 1 || issues[0].Level != tt.level {
+			t.Errorf("CheckConfusables(%v) = %v, want one %s", tt.names, issues, tt.level)
+		}
+	}
+}
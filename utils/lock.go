@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Lock is a lock file held by the running process
+type Lock struct {
+	Path string
+	file *os.File
+}
+
+// LockPath returns the lock file of the zone in the cache directory
+func LockPath(dir string, zone string) string {
+	if dir == "" {
+		dir = filepath.Join(CacheDir(), "locks")
+	}
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, strings.ToLower(zone))
+	return filepath.Join(dir, name+".lock")
+}
+
+// AcquireLock locks the lock file, it fails when another process holds it.
+// The system releases the lock when the process exits, so a lock left by a
+// dead process is free again.
+func AcquireLock(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		holder, _ := os.ReadFile(path)
+		if len(holder) == 0 {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		return nil, fmt.Errorf("%s is held by %s", path, strings.TrimSpace(string(holder)))
+	}
+	host, _ := os.Hostname()
+	if err := f.Truncate(0); err == nil {
+		fmt.Fprintf(f, "pid %d on %s since %s\n", os.Getpid(), host, time.Now().Format(time.RFC3339))
+	}
+	return &Lock{Path: path, file: f}, nil
+}
+
+// Release unlocks the lock file, the file is kept so the next process locks the same file
+func (l *Lock) Release() error {
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAcquireLock(t *testing.T) {
+	path := LockPath(t.TempDir(), "Example.com")
+	if filepath.Base(path) != "example.com.lock" {
+		t.Errorf("LockPath() = %q", path)
+	}
+	lock, err := AcquireLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AcquireLock(path); err == nil {
+		t.Fatal("AcquireLock() of a held lock succeeded")
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	lock, err = AcquireLock(path)
+	if err != nil {
+		t.Fatalf("AcquireLock() after Release() = %v", err)
+	}
+	lock.Release()
+}
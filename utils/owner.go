@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// usernameRegex matches a GitHub style username
+var usernameRegex = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]{0,37}[A-Za-z0-9])?$`)
+
+// ValidUsername checks if the username is well-formed
+func ValidUsername(username string) bool {
+	return usernameRegex.MatchString(username) && !strings.Contains(username, "--")
+}
+
+// ValidEmail checks if the email is a bare address
+func ValidEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	return err == nil && address.Address == email
+}
+
+// OwnerLimitFor returns the limit which applies to the owner
+func OwnerLimitFor(policy models.OwnerPolicy, username string) models.OwnerLimit {
+	for name, limit := range policy.Overrides {
+		if strings.EqualFold(name, username) {
+			return limit
+		}
+	}
+	return policy.Default
+}
+
+// CheckOwners reports missing owners, names shared by several owners, exceeded
+// quotas and subdomains claimed under another owner
+func CheckOwners(records []models.Records, policy models.OwnerPolicy, domain string) []Issue {
+	var issues []Issue
+	ownerLevel := LevelError
+	if policy.Optional {
+		ownerLevel = LevelWarning
+	}
+
+	// names claimed by each owner and the first owner of each name
+	claims := make(map[string][]string)
+	nameOwner := make(map[string]string)
+	for _, entry := range records {
+		name := TrimDomain(entry.Record.Name, domain)
+		username := entry.Owner.Username
+		if username == "" {
+			issues = append(issues, Issue{Level: ownerLevel, Name: name, Message: "owner username is missing", Rule: RuleOwnerUsername})
+		} else if !ValidUsername(username) {
+			issues = append(issues, Issue{Level: ownerLevel, Name: name, Message: fmt.Sprintf("owner username %q is invalid", username), Rule: RuleOwnerUsername})
+		}
+		if entry.Owner.Email == "" {
+			issues = append(issues, Issue{Level: ownerLevel, Name: name, Message: "owner email is missing", Rule: RuleOwnerEmail})
+		} else if !ValidEmail(entry.Owner.Email) {
+			issues = append(issues, Issue{Level: ownerLevel, Name: name, Message: fmt.Sprintf("owner email %q is invalid", entry.Owner.Email), Rule: RuleOwnerEmail})
+		}
+		if username == "" {
+			continue
+		}
+
+		limit := OwnerLimitFor(policy, username)
+		if len(limit.AllowedTypes) > 0 && !TypeContains(limit.AllowedTypes, entry.Record.Type) {
+			issues = append(issues, Issue{
+				Level:   LevelError,
+				Name:    name,
+				Message: fmt.Sprintf("%s may not create %s records (allowed: %s)", username, entry.Record.Type, strings.Join(limit.AllowedTypes, ", ")),
+				Rule:    RuleAllowedTypes,
+			})
+		}
+		key := strings.ToLower(username)
+		if first, ok := nameOwner[name]; !ok {
+			nameOwner[name] = username
+		} else if !strings.EqualFold(first, username) {
+			issues = append(issues, Issue{
+				Level:   LevelError,
+				Name:    name,
+				Message: fmt.Sprintf("claimed by %s but already owned by %s", username, first),
+				Rule:    RuleSharedName,
+			})
+		}
+		if !TypeContains(claims[key], name) {
+			claims[key] = append(claims[key], name)
+		}
+	}
+
+	// check the quota of every owner
+	var owners []string
+	for owner := range claims {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+	for _, owner := range owners {
+		limit := OwnerLimitFor(policy, owner)
+		if limit.MaxSubdomains > 0 && len(claims[owner]) > limit.MaxSubdomains {
+			issues = append(issues, Issue{
+				Level:   LevelError,
+				Name:    owner,
+				Message: fmt.Sprintf("owns %d subdomains, the limit is %d", len(claims[owner]), limit.MaxSubdomains),
+				Rule:    RuleQuota,
+			})
+		}
+	}
+
+	// check subdomains which are children of another owner's subdomain
+	for _, owner := range owners {
+		for _, name := range claims[owner] {
+			for _, other := range owners {
+				if other == owner {
+					continue
+				}
+				for _, parent := range claims[other] {
+					if parent != "@" && strings.HasSuffix(name, "."+parent) {
+						issues = append(issues, Issue{
+							Level:   LevelError,
+							Name:    name,
+							Message: fmt.Sprintf("claimed by %s but %s is owned by %s", owner, parent, other),
+							Rule:    RuleParentOwner,
+						})
+					}
+				}
+			}
+		}
+	}
+	return issues
+}
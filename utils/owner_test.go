@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+func owned(username, email, recordType, name string) models.Records {
+	return models.Records{
+		Owner:  models.Owner{Username: username, Email: email},
+		Record: models.Record{Type: recordType, Name: name, Content: "alice.github.io"},
+	}
+}
+
+func checkIssues(t *testing.T, issues, want []Issue) {
+	t.Helper()
+	if len(issues) != len(want) {
+		t.Fatalf("got %v, want %d issues", issues, len(want))
+	}
+	for i := range want {
+		if issues[i].Level != want[i].Level || issues[i].Name != want[i].Name || issues[i].Rule != want[i].Rule {
+			t.Errorf("issue %d = %v (%s), want %s on %s (%s)", i, issues[i], issues[i].Rule, want[i].Level, want[i].Name, want[i].Rule)
+		}
+	}
+}
+
+func TestCheckOwnersMissingOwner(t *testing.T) {
+	records := []models.Records{
+		owned("alice", "alice@example.com", "CNAME", "blog.example.com"),
+		owned("", "", "CNAME", "nobody"),
+		owned("-bad", "alice <alice@example.com>", "CNAME", "bad"),
+	}
+	want := []Issue{
+		{Level: LevelError, Name: "nobody", Rule: RuleOwnerUsername},
+		{Level: LevelError, Name: "nobody", Rule: RuleOwnerEmail},
+		{Level: LevelError, Name: "bad", Rule: RuleOwnerUsername},
+		{Level: LevelError, Name: "bad", Rule: RuleOwnerEmail},
+	}
+	checkIssues(t, CheckOwners(records, models.OwnerPolicy{}, "example.com"), want)
+
+	// the policy can make the owner optional
+	for i := range want {
+		want[i].Level = LevelWarning
+	}
+	checkIssues(t, CheckOwners(records, models.OwnerPolicy{Optional: true}, "example.com"), want)
+}
+
+func TestCheckOwnersLimits(t *testing.T) {
+	policy := models.OwnerPolicy{
+		Default: models.OwnerLimit{MaxSubdomains: 2, AllowedTypes: []string{"CNAME", "TXT"}},
+		Overrides: map[string]models.OwnerLimit{
+			"Bob": {MaxSubdomains: 3, AllowedTypes: []string{"A", "CNAME"}},
+		},
+	}
+	records := []models.Records{
+		owned("alice", "alice@example.com", "CNAME", "a"),
+		// another record of the same name is not counted twice
+		owned("alice", "alice@example.com", "TXT", "a"),
+		owned("Alice", "alice@example.com", "CNAME", "b"),
+		owned("alice", "alice@example.com", "A", "c"),
+		owned("bob", "bob@example.com", "A", "x"),
+		owned("bob", "bob@example.com", "A", "y"),
+		owned("bob", "bob@example.com", "CNAME", "z"),
+		owned("bob", "bob@example.com", "TXT", "w"),
+	}
+	checkIssues(t, CheckOwners(records, policy, "example.com"), []Issue{
+		{Level: LevelError, Name: "c", Rule: RuleAllowedTypes},
+		{Level: LevelError, Name: "w", Rule: RuleAllowedTypes},
+		{Level: LevelError, Name: "alice", Rule: RuleQuota},
+		{Level: LevelError, Name: "bob", Rule: RuleQuota},
+	})
+}
+
+func TestCheckOwnersClaims(t *testing.T) {
+	records := []models.Records{
+		owned("alice", "alice@example.com", "CNAME", "@"),
+		owned("alice", "alice@example.com", "CNAME", "alice"),
+		owned("alice", "alice@example.com", "CNAME", "www.alice"),
+		owned("bob", "bob@example.com", "CNAME", "x.alice"),
+		owned("bob", "bob@example.com", "CNAME", "bob"),
+		// the same name claimed by a second owner
+		owned("carol", "carol@example.com", "TXT", "bob"),
+		owned("BOB", "bob@example.com", "TXT", "bob"),
+		// the children of the apex are not claimed by its owner
+		owned("carol", "carol@example.com", "CNAME", "carol"),
+	}
+	checkIssues(t, CheckOwners(records, models.OwnerPolicy{}, "example.com"), []Issue{
+		{Level: LevelError, Name: "bob", Rule: RuleSharedName},
+		{Level: LevelError, Name: "x.alice", Rule: RuleParentOwner},
+	})
+}
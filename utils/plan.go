@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// Status of a record when the records file is compared with the zone
+const (
+	StatusInSync     = "in sync"
+	StatusCreate     = "pending create"
+	StatusUpdate     = "pending update"
+	StatusRemoteOnly = "remote-only"
+	StatusRestricted = "restricted"
+)
+
+// Change is the status of a record name, Local is empty for remote-only
+// records and Remote is empty for the records to create
+type Change struct {
+	Status string
+	Local  models.Records
+	Remote models.Record
+}
+
+// Name returns the fully qualified name of the change
+func (c Change) Name() string {
+	if c.Local.Record.Name != "" {
+		return c.Local.Record.Name
+	}
+	return c.Remote.Name
+}
+
+// PlanChanges compares the local entries with the remote records by name like
+// sync does. The local names must be fully qualified and normalised, the
+// restricted local entries are never synced so their remote record is removed.
+func PlanChanges(local []models.Records, remote []models.Record, restricted *RestrictedList, domain string) []Change {
+	var changes []Change
+	synced := make(map[string]bool)
+	for _, entry := range local {
+		record := entry.Record
+		if restricted.IsRestricted(TrimDomain(record.Name, domain)) {
+			changes = append(changes, Change{Status: StatusRestricted, Local: entry})
+			continue
+		}
+		synced[record.Name] = true
+		r := FindRecordByName(remote, record.Name)
+		switch {
+		case r.ID == "":
+			changes = append(changes, Change{Status: StatusCreate, Local: entry})
+		case r.Content != record.Content || r.Proxied != record.Proxied || r.Name != record.Name || !SameTTL(r.TTL, record.TTL):
+			changes = append(changes, Change{Status: StatusUpdate, Local: entry, Remote: r})
+		default:
+			changes = append(changes, Change{Status: StatusInSync, Local: entry, Remote: r})
+		}
+	}
+	for _, r := range remote {
+		if !synced[r.Name] {
+			changes = append(changes, Change{Status: StatusRemoteOnly, Remote: r})
+		}
+	}
+	return changes
+}
+
+// SameTTL compares two TTLs, 1 is automatic and an unset TTL is pushed as automatic
+func SameTTL(a uint, b uint) bool {
+	if a == 0 {
+		a = 1
+	}
+	if b == 0 {
+		b = 1
+	}
+	return a == b
+}
+
+// ChangesWith returns the changes of the status
+func ChangesWith(changes []Change, status string) []Change {
+	var selected []Change
+	for _, change := range changes {
+		if change.Status == status {
+			selected = append(selected, change)
+		}
+	}
+	return selected
+}
+
+// SelectChanges keeps the changes of the named records and of the entries
+// owned by one of the owners (username or email). Remote-only records have no
+// owner so they are only selected by name. The names which match no change
+// are returned too.
+func SelectChanges(changes []Change, names []string, owners []string, domain string) ([]Change, []string, error) {
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		canonical, err := CanonicalName(name, domain)
+		if err != nil {
+			return nil, nil, err
+		}
+		wanted[canonical] = true
+	}
+	found := make(map[string]bool)
+	var selected []Change
+	for _, change := range changes {
+		name := TrimDomain(change.Name(), domain)
+		owner := change.Local.Owner
+		switch {
+		case wanted[name]:
+			found[name] = true
+		case owner.Username != "" && containsFold(owners, owner.Username):
+		case owner.Email != "" && containsFold(owners, owner.Email):
+		default:
+			continue
+		}
+		selected = append(selected, change)
+	}
+	var missing []string
+	for _, name := range names {
+		if canonical, _ := CanonicalName(name, domain); !found[canonical] {
+			missing = append(missing, name)
+		}
+	}
+	return selected, missing, nil
+}
+
+// containsFold checks if the list holds the value ignoring the case
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+func TestPlanChanges(t *testing.T) {
+	local := []models.Records{
+		{Record: models.Record{Type: "A", Name: "same.example.com", Content: "1.1.1.1"}},
+		{Record: models.Record{Type: "A", Name: "auto.example.com", Content: "1.1.1.1", TTL: 1}},
+		{Record: models.Record{Type: "A", Name: "ttl.example.com", Content: "1.1.1.1", TTL: 3600}},
+		{Record: models.Record{Type: "A", Name: "unset.example.com", Content: "1.1.1.1"}},
+		{Record: models.Record{Type: "A", Name: "content.example.com", Content: "2.2.2.2", TTL: 1}},
+		{Record: models.Record{Type: "A", Name: "proxied.example.com", Content: "1.1.1.1", Proxied: true}},
+		{Record: models.Record{Type: "A", Name: "new.example.com", Content: "1.1.1.1"}},
+	}
+	remote := []models.Record{
+		{ID: "1", Type: "A", Name: "same.example.com", Content: "1.1.1.1", TTL: 1},
+		{ID: "2", Type: "A", Name: "auto.example.com", Content: "1.1.1.1", TTL: 1},
+		{ID: "3", Type: "A", Name: "ttl.example.com", Content: "1.1.1.1", TTL: 1},
+		{ID: "4", Type: "A", Name: "unset.example.com", Content: "1.1.1.1", TTL: 300},
+		{ID: "5", Type: "A", Name: "content.example.com", Content: "1.1.1.1", TTL: 1},
+		{ID: "6", Type: "A", Name: "proxied.example.com", Content: "1.1.1.1", TTL: 1},
+		{ID: "7", Type: "A", Name: "old.example.com", Content: "1.1.1.1", TTL: 1},
+	}
+	want := map[string]string{
+		"same.example.com":    StatusInSync,
+		"auto.example.com":    StatusInSync,
+		"ttl.example.com":     StatusUpdate,
+		"unset.example.com":   StatusUpdate,
+		"content.example.com": StatusUpdate,
+		"proxied.example.com": StatusUpdate,
+		"new.example.com":     StatusCreate,
+		"old.example.com":     StatusRemoteOnly,
+	}
+	changes := PlanChanges(local, remote, nil, "example.com")
+	if len(changes) != len(want) {
+		t.Fatalf("PlanChanges() returned %d changes, want %d", len(changes), len(want))
+	}
+	for _, change := range changes {
+		if change.Status != want[change.Name()] {
+			t.Errorf("%s: status = %q, want %q", change.Name(), change.Status, want[change.Name()])
+		}
+	}
+}
+
+func TestPlanChangesRestricted(t *testing.T) {
+	restricted := &RestrictedList{Rules: []RestrictedRule{{Pattern: "api"}}}
+	if err := restricted.Rules[0].compile(); err != nil {
+		t.Fatal(err)
+	}
+	local := []models.Records{{Record: models.Record{Type: "A", Name: "api.example.com", Content: "1.1.1.1"}}}
+	remote := []models.Record{{ID: "1", Type: "A", Name: "api.example.com", Content: "1.1.1.1", TTL: 1}}
+	changes := PlanChanges(local, remote, restricted, "example.com")
+	if len(changes) != 2 || changes[0].Status != StatusRestricted || changes[1].Status != StatusRemoteOnly {
+		t.Errorf("PlanChanges() = %+v, want restricted and remote-only", changes)
+	}
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// AutoTTL is the TTL value Cloudflare uses for automatic TTL
+const AutoTTL = 1
+
+// ProxiableTypes are the record types Cloudflare can proxy
+var ProxiableTypes = []string{"A", "AAAA", "CNAME"}
+
+// IsProxiable checks if Cloudflare can proxy the record type
+func IsProxiable(recordType string) bool {
+	return TypeContains(ProxiableTypes, recordType)
+}
+
+// TypeDefaultFor returns the default proxied state and TTL of the record type.
+// Proxiable types are proxied with automatic TTL unless the config says otherwise.
+func TypeDefaultFor(defaults map[string]models.TypeDefault, recordType string) (proxied bool, ttl uint) {
+	proxied, ttl = IsProxiable(recordType), AutoTTL
+	if d, ok := defaults[recordType]; ok {
+		if d.Proxied != nil {
+			proxied = *d.Proxied && IsProxiable(recordType)
+		}
+		if d.TTL != 0 {
+			ttl = d.TTL
+		}
+	}
+	return proxied, ttl
+}
+
+// WantsProxied checks if the entry should be proxied by default
+func WantsProxied(entry models.Records, defaults map[string]models.TypeDefault) bool {
+	proxied, _ := TypeDefaultFor(defaults, entry.Record.Type)
+	return proxied && !entry.DNSOnly
+}
+
+// ApplyTypeDefaults applies the proxy and TTL policy of the record type to the entry
+// and returns the changes made
+func ApplyTypeDefaults(entry *models.Records, defaults map[string]models.TypeDefault) []string {
+	var changes []string
+	record := &entry.Record
+	proxiable := IsProxiable(record.Type)
+	record.Proxiable = proxiable
+
+	switch {
+	case record.Proxied && !proxiable:
+		record.Proxied = false
+		changes = append(changes, fmt.Sprintf("Setting Proxied to false, %s records cannot be proxied", record.Type))
+	case record.Proxied && entry.DNSOnly:
+		record.Proxied = false
+		changes = append(changes, "Setting Proxied to false, the record is DNS only")
+	case !record.Proxied && WantsProxied(*entry, defaults):
+		record.Proxied = true
+		changes = append(changes, "Setting Proxied to true")
+	}
+
+	_, ttl := TypeDefaultFor(defaults, record.Type)
+	if record.Proxied {
+		// proxied records always use automatic TTL
+		ttl = AutoTTL
+	}
+	if record.TTL == 0 || (record.Proxied && record.TTL != AutoTTL) {
+		record.TTL = ttl
+		if ttl == AutoTTL {
+			changes = append(changes, "Setting TTL to auto")
+		} else {
+			changes = append(changes, fmt.Sprintf("Setting TTL to %d", ttl))
+		}
+	}
+	return changes
+}
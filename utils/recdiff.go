@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// Kinds of differences between two records files
+const (
+	DiffAdded    = "added"
+	DiffRemoved  = "removed"
+	DiffModified = "modified"
+)
+
+// EntryDiff is an entry which differs between two records files,
+// Base is empty for added entries and Head for removed ones
+type EntryDiff struct {
+	Kind string
+	Name string
+	Type string
+	Base models.Records
+	Head models.Records
+}
+
+// canonicalEntry returns the entry with its canonical name and the key it is compared by
+func canonicalEntry(entry models.Records, domain string) (models.Records, string) {
+	name, err := CanonicalName(entry.Record.Name, domain)
+	if err != nil {
+		name = strings.ToLower(strings.TrimSpace(entry.Record.Name))
+	}
+	entry.Record.Name = name
+	return entry, name + " " + strings.ToUpper(entry.Record.Type)
+}
+
+// DiffRecords compares two records files entry by entry, the entries are
+// matched by name and type so reordering or renaming to the canonical name
+// is not a difference
+func DiffRecords(base []models.Records, head []models.Records, domain string) []EntryDiff {
+	baseByKey := make(map[string][]models.Records)
+	var baseKeys []string
+	for _, entry := range base {
+		entry, key := canonicalEntry(entry, domain)
+		if _, ok := baseByKey[key]; !ok {
+			baseKeys = append(baseKeys, key)
+		}
+		baseByKey[key] = append(baseByKey[key], entry)
+	}
+
+	var diffs []EntryDiff
+	seen := make(map[string]int)
+	for _, entry := range head {
+		entry, key := canonicalEntry(entry, domain)
+		i := seen[key]
+		seen[key]++
+		if i >= len(baseByKey[key]) {
+			diffs = append(diffs, EntryDiff{Kind: DiffAdded, Name: entry.Record.Name, Type: entry.Record.Type, Head: entry})
+			continue
+		}
+		if old := baseByKey[key][i]; !reflect.DeepEqual(old, entry) {
+			diffs = append(diffs, EntryDiff{Kind: DiffModified, Name: entry.Record.Name, Type: entry.Record.Type, Base: old, Head: entry})
+		}
+	}
+	for _, key := range baseKeys {
+		for i, entry := range baseByKey[key] {
+			if i >= seen[key] {
+				diffs = append(diffs, EntryDiff{Kind: DiffRemoved, Name: entry.Record.Name, Type: entry.Record.Type, Base: entry})
+			}
+		}
+	}
+	return diffs
+}
+
+// DiffNames returns the names of the differences once each
+func DiffNames(diffs []EntryDiff) []string {
+	var names []string
+	for _, diff := range diffs {
+		if !TypeContains(names, diff.Name) {
+			names = append(names, diff.Name)
+		}
+	}
+	return names
+}
+
+// DiffsWith returns the differences of the kind
+func DiffsWith(diffs []EntryDiff, kind string) []EntryDiff {
+	var selected []EntryDiff
+	for _, diff := range diffs {
+		if diff.Kind == kind {
+			selected = append(selected, diff)
+		}
+	}
+	return selected
+}
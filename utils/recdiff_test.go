@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+func TestDiffRecords(t *testing.T) {
+	entry := func(name string, recordType string, content string) models.Records {
+		return models.Records{Owner: models.Owner{Username: "alice"}, Record: models.Record{Name: name, Type: recordType, Content: content}}
+	}
+	base := []models.Records{
+		entry("same", "A", "1.1.1.1"),
+		entry("Canonical.example.com.", "A", "1.1.1.1"),
+		entry("changed", "A", "1.1.1.1"),
+		entry("removed", "CNAME", "x.github.io"),
+		entry("old-name", "A", "2.2.2.2"),
+		entry("both", "A", "3.3.3.3"),
+		entry("both", "AAAA", "::3"),
+	}
+	head := []models.Records{
+		entry("new-name", "A", "2.2.2.2"),
+		entry("canonical", "A", "1.1.1.1"),
+		entry("changed", "A", "9.9.9.9"),
+		entry("both", "AAAA", "::3"),
+		entry("same", "A", "1.1.1.1"),
+		entry("added", "TXT", "hello"),
+		entry("both", "A", "3.3.3.3"),
+	}
+	diffs := DiffRecords(base, head, "example.com")
+	want := []EntryDiff{
+		{Kind: DiffAdded, Name: "new-name", Type: "A"},
+		{Kind: DiffModified, Name: "changed", Type: "A"},
+		{Kind: DiffAdded, Name: "added", Type: "TXT"},
+		{Kind: DiffRemoved, Name: "removed", Type: "CNAME"},
+		{Kind: DiffRemoved, Name: "old-name", Type: "A"},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("DiffRecords() = %+v, want %d differences", diffs, len(want))
+	}
+	for i := range want {
+		if diffs[i].Kind != want[i].Kind || diffs[i].Name != want[i].Name || diffs[i].Type != want[i].Type {
+			t.Errorf("difference %d = %s %s %s, want %s %s %s", i, diffs[i].Kind, diffs[i].Name, diffs[i].Type, want[i].Kind, want[i].Name, want[i].Type)
+		}
+	}
+	if diffs[1].Base.Record.Content != "1.1.1.1" || diffs[1].Head.Record.Content != "9.9.9.9" {
+		t.Errorf("modified entry = %+v", diffs[1])
+	}
+	if diffs[0].Base.Record.Name != "" || diffs[3].Head.Record.Name != "" {
+		t.Errorf("added entries have no base and removed ones no head: %+v", diffs)
+	}
+
+	names := DiffNames(append(diffs, EntryDiff{Kind: DiffModified, Name: "changed", Type: "AAAA"}))
+	if len(names) != 5 || names[0] != "new-name" || names[4] != "old-name" {
+		t.Errorf("DiffNames() = %v", names)
+	}
+	if got := DiffsWith(diffs, DiffRemoved); len(got) != 2 {
+		t.Errorf("DiffsWith(removed) = %+v", got)
+	}
+}
+
+func TestDiffRecordsInvalidName(t *testing.T) {
+	base := []models.Records{{Record: models.Record{Name: "bad name", Type: "A", Content: "1.1.1.1"}}}
+	diffs := DiffRecords(base, nil, "example.com")
+	if len(diffs) != 1 || diffs[0].Name != "bad name" {
+		t.Fatalf("DiffRecords() = %+v, want the invalid entry removed", diffs)
+	}
+	// the caller must see the name is not canonical
+	if _, err := CanonicalName(diffs[0].Name, "example.com"); err == nil {
+		t.Errorf("CanonicalName(%q) succeeded", diffs[0].Name)
+	}
+}
@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// Matching modes for restricted entries
+const (
+	MatchExact = "exact"
+	MatchGlob  = "glob"
+	MatchRegex = "regex"
+)
+
+// Scopes for restricted entries
+const (
+	ScopeLabel = "label"
+	ScopeName  = "name"
+)
+
+// RestrictedRule is a single entry of the restricted subdomain list.
+// A plain string in restricted.json is a regex matched against every label.
+type RestrictedRule struct {
+	Pattern string `json:"pattern"`
+	Match   string `json:"match,omitempty"`
+	Scope   string `json:"scope,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// UnmarshalJSON accepts both the plain string and the object form
+func (r *RestrictedRule) UnmarshalJSON(data []byte) error {
+	var pattern string
+	if err := json.Unmarshal(data, &pattern); err == nil {
+		*r = RestrictedRule{Pattern: pattern}
+		return nil
+	}
+	type rule RestrictedRule
+	var v rule
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*r = RestrictedRule(v)
+	return nil
+}
+
+// String explains the rule in words
+func (r RestrictedRule) String() string {
+	target := "any label"
+	if r.Scope == ScopeName {
+		target = "the full name"
+	}
+	return fmt.Sprintf("%s pattern %q on %s", r.Match, r.Pattern, target)
+}
+
+// comment checks if the entry is a blank line or a `#` comment of the list
+func (r RestrictedRule) comment() bool {
+	pattern := strings.TrimSpace(r.Pattern)
+	return r.Match == "" && r.Scope == "" && (pattern == "" || strings.HasPrefix(pattern, "#"))
+}
+
+// dotted checks if the pattern can only match across labels
+func (r RestrictedRule) dotted() bool {
+	if r.Match == MatchRegex {
+		return strings.Contains(r.Pattern, `\.`)
+	}
+	return strings.Contains(r.Pattern, ".")
+}
+
+// compile validates the rule and prepares the anchored expression.
+// A pattern with a dot never matches a single label, it is matched against
+// the full name unless the label scope is asked explicitly.
+func (r *RestrictedRule) compile() error {
+	if r.Match == "" {
+		r.Match = MatchRegex
+	}
+	if r.Pattern == "" {
+		return fmt.Errorf("pattern cannot be empty")
+	}
+	if r.Scope == "" {
+		r.Scope = ScopeLabel
+		if r.dotted() {
+			r.Scope = ScopeName
+		}
+	} else if r.Scope == ScopeLabel && r.dotted() {
+		return fmt.Errorf("a label never contains a dot, use the name scope")
+	}
+	var expr string
+	switch r.Match {
+	case MatchExact:
+		expr = regexp.QuoteMeta(r.Pattern)
+	case MatchGlob:
+		expr = globToRegex(r.Pattern)
+	case MatchRegex:
+		expr = r.Pattern
+	default:
+		return fmt.Errorf("unknown match %q (use exact, glob or regex)", r.Match)
+	}
+	if r.Scope != ScopeLabel && r.Scope != ScopeName {
+		return fmt.Errorf("unknown scope %q (use label or name)", r.Scope)
+	}
+	re, err := regexp.Compile("(?i)^(?:" + expr + ")$")
+	if err != nil {
+		return err
+	}
+	r.re = re
+	return nil
+}
+
+// matches checks the relative name against the rule
+func (r RestrictedRule) matches(name string) bool {
+	if r.re == nil {
+		return false
+	}
+	if r.Scope == ScopeName {
+		return r.re.MatchString(name)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if r.re.MatchString(label) {
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegex converts a shell style glob to a regular expression
+func globToRegex(glob string) string {
+	var b strings.Builder
+	for _, c := range glob {
+		switch c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// RestrictedList is the compiled list of restricted subdomains
+type RestrictedList struct {
+	Rules []RestrictedRule `json:"restricted_subdomain"`
+}
+
+// LoadRestricted reads and compiles the restricted subdomains file
+func LoadRestricted(filename string) (*RestrictedList, error) {
+	list := &RestrictedList{}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return list, err
+	}
+	if err := json.Unmarshal(data, list); err != nil {
+		return &RestrictedList{}, fmt.Errorf("%s: %v", filename, err)
+	}
+	var errs []string
+	var rules []RestrictedRule
+	for i, rule := range list.Rules {
+		if rule.comment() {
+			continue
+		}
+		if err := rule.compile(); err != nil {
+			errs = append(errs, fmt.Sprintf("entry %d (%q): %v", i+1, rule.Pattern, err))
+		}
+		rules = append(rules, rule)
+	}
+	if len(errs) > 0 {
+		return &RestrictedList{}, fmt.Errorf("%s: invalid restricted entries:\n\t%s", filename, strings.Join(errs, "\n\t"))
+	}
+	list.Rules = rules
+	return list, nil
+}
+
+// Match returns the rule which restricts the name.
+// The name must be relative to the domain e.g. `api` or `x.api`.
+func (l *RestrictedList) Match(name string) (RestrictedRule, bool) {
+	if l == nil || name == "" || name == "@" {
+		return RestrictedRule{}, false
+	}
+	for _, rule := range l.Rules {
+		if rule.matches(name) {
+			return rule, true
+		}
+	}
+	return RestrictedRule{}, false
+}
+
+// IsRestricted checks if the relative name is restricted
+func (l *RestrictedList) IsRestricted(name string) bool {
+	_, ok := l.Match(name)
+	return ok
+}
+
+// TrimDomain returns the name relative to the domain
+func TrimDomain(name string, domain string) string {
+	name = strings.TrimSuffix(name, ".")
+	if domain == "" {
+		return name
+	}
+	if strings.EqualFold(name, domain) {
+		return "@"
+	}
+	// compare the last labels of the name itself with the domain
+	if i := len(name) - len(domain); i > 0 && name[i-1] == '.' && strings.EqualFold(name[i:], domain) {
+		return name[:i-1]
+	}
+	return name
+}
+
+// RemoveRestrictedSubdomains splits the records into allowed and restricted ones
+func RemoveRestrictedSubdomains(restricted *RestrictedList, domain string, localRecords []models.Record) (localNonRestrictedRecords []models.Record, localRestrictedRecords []models.Record) {
+	for _, record := range localRecords {
+		if !restricted.IsRestricted(TrimDomain(record.Name, domain)) {
+			localNonRestrictedRecords = append(localNonRestrictedRecords, record)
+		} else {
+			localRestrictedRecords = append(localRestrictedRecords, record)
+		}
+	}
+	return localNonRestrictedRecords, localRestrictedRecords
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadRestrictedList writes the restricted file and loads it
+func loadRestrictedList(t *testing.T, content string) (*RestrictedList, error) {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "restricted.json")
+	if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return LoadRestricted(filename)
+}
+
+func TestRestrictedMatch(t *testing.T) {
+	list, err := loadRestrictedList(t, `{"restricted_subdomain": [
+		"# reserved for the infrastructure",
+		"api",
+		"ww([0-9]+)",
+		"",
+		"   ",
+		{"pattern": "admin", "match": "exact", "scope": "name"},
+		{"pattern": "mail.corp", "match": "exact"},
+		{"pattern": "cdn-*", "match": "glob"},
+		{"pattern": "*.internal", "match": "glob"},
+		{"pattern": "status\\.ops", "match": "regex", "scope": "name"}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Rules) != 7 {
+		t.Errorf("LoadRestricted() kept %d rules, want the comment and blank entries skipped", len(list.Rules))
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		// regex on every label, anchored
+		{"api", "api"},
+		{"x.api", "api"},
+		{"api.x", "api"},
+		{"API", "api"},
+		{"myapi", ""},
+		{"rapid", ""},
+		{"apis", ""},
+		{"ww1", "ww([0-9]+)"},
+		{"www", ""},
+		// exact on the full name
+		{"admin", "admin"},
+		{"x.admin", ""},
+		{"admins", ""},
+		// exact with a dot is matched against the full name
+		{"mail.corp", "mail.corp"},
+		{"x.mail.corp", ""},
+		{"mail", ""},
+		// glob on every label
+		{"cdn-eu", "cdn-*"},
+		{"x.cdn-eu", "cdn-*"},
+		{"cdn", ""},
+		// glob with a dot is matched against the full name
+		{"db.internal", "*.internal"},
+		{"a.b.internal", "*.internal"},
+		{"internal", ""},
+		{"db.internal.x", ""},
+		// regex on the full name
+		{"status.ops", `status\.ops`},
+		{"statusxops", ""},
+		{"@", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		rule, ok := list.Match(tt.name)
+		if ok != (tt.want != "") || rule.Pattern != tt.want {
+			t.Errorf("Match(%q) = %q, %t, want %q", tt.name, rule.Pattern, ok, tt.want)
+		}
+	}
+}
+
+func TestRestrictedRuleString(t *testing.T) {
+	list, err := loadRestrictedList(t, `{"restricted_subdomain": ["api", {"pattern": "*.internal", "match": "glob"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"x.api":       `regex pattern "api" on any label`,
+		"db.internal": `glob pattern "*.internal" on the full name`,
+	} {
+		if rule, _ := list.Match(name); rule.String() != want {
+			t.Errorf("Match(%q).String() = %q, want %q", name, rule.String(), want)
+		}
+	}
+}
+
+func TestLoadRestrictedErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`{"restricted_subdomain": ["ok", "ww(["]}`, `entry 2 ("ww(["): error parsing regexp`},
+		{`{"restricted_subdomain": [{"pattern": "x", "match": "fuzzy"}]}`, `unknown match "fuzzy"`},
+		{`{"restricted_subdomain": [{"pattern": "x", "scope": "zone"}]}`, `unknown scope "zone"`},
+		{`{"restricted_subdomain": [{"pattern": "", "match": "exact"}]}`, `pattern cannot be empty`},
+		{`{"restricted_subdomain": [{"pattern": "*.internal", "match": "glob", "scope": "label"}]}`, `use the name scope`},
+		{`{"restricted_subdomain": [`, `restricted.json`},
+	}
+	for _, tt := range tests {
+		list, err := loadRestrictedList(t, tt.content)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadRestricted(%s) = %v, want an error with %q", tt.content, err, tt.want)
+		}
+		if list == nil || len(list.Rules) != 0 {
+			t.Errorf("LoadRestricted(%s) returned rules with an error", tt.content)
+		}
+	}
+	if _, err := LoadRestricted(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("LoadRestricted(missing) = %v, want a not exist error", err)
+	}
+}
+
+func TestTrimDomain(t *testing.T) {
+	tests := []struct {
+		name   string
+		domain string
+		want   string
+	}{
+		{"blog.example.com", "example.com", "blog"},
+		{"blog.example.com.", "example.com", "blog"},
+		{"Blog.EXAMPLE.com", "example.com", "Blog"},
+		{"example.com", "example.com", "@"},
+		{"Example.com.", "example.com", "@"},
+		{"myexample.com", "example.com", "myexample.com"},
+		{"blog.other.com", "example.com", "blog.other.com"},
+		{"blog", "", "blog"},
+		// a name whose lowercase form has another length
+		{"İ.example.com", "example.com", "İ"},
+		{"İ", "example.com", "İ"},
+		{"x.İxample.com", "example.com", "x.İxample.com"},
+	}
+	for _, tt := range tests {
+		if got := TrimDomain(tt.name, tt.domain); got != tt.want {
+			t.Errorf("TrimDomain(%q, %q) = %q, want %q", tt.name, tt.domain, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// PassphraseEnv is the environment variable holding the passphrase of the secrets file
+const PassphraseEnv = "MRINJAMULCF_PASSPHRASE"
+
+// Key derivation parameters of the secrets file
+const (
+	secretsVersion    = 1
+	secretsKDF        = "pbkdf2-sha256"
+	secretsIterations = 210000
+	secretsKeyLen     = 32
+)
+
+// secretsEnvelope is the encrypted secrets file
+type secretsEnvelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// SecretsPath returns the default secrets file in $XDG_CONFIG_HOME
+func SecretsPath() string {
+	return filepath.Join(filepath.Dir(XDGConfigPath()), "secrets.json")
+}
+
+// SecretsFile returns the secrets file of the config, the default one is
+// used only if it exists
+func SecretsFile(config models.Config) string {
+	if config.SecretsFile != "" {
+		return config.SecretsFile
+	}
+	if _, err := os.Stat(SecretsPath()); err == nil {
+		return SecretsPath()
+	}
+	return ""
+}
+
+// secretsCipher returns the AES-GCM cipher for the passphrase and salt
+func secretsCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, secretsKeyLen, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptSecrets encrypts the secrets with the passphrase
+func EncryptSecrets(secrets map[string]string, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase cannot be empty")
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	env := secretsEnvelope{Version: secretsVersion, KDF: secretsKDF, Iterations: secretsIterations, Salt: make([]byte, 16)}
+	if _, err := rand.Read(env.Salt); err != nil {
+		return nil, err
+	}
+	aead, err := secretsCipher(passphrase, env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return nil, err
+	}
+	env.Data = aead.Seal(nil, env.Nonce, plain, nil)
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// DecryptSecrets decrypts the secrets file content with the passphrase
+func DecryptSecrets(data []byte, passphrase string) (map[string]string, error) {
+	var env secretsEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+	if env.Version != secretsVersion || env.KDF != secretsKDF {
+		return nil, fmt.Errorf("unsupported secrets file version %d (%s)", env.Version, env.KDF)
+	}
+	aead, err := secretsCipher(passphrase, env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce in secrets file")
+	}
+	plain, err := aead.Open(nil, env.Nonce, env.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt the secrets, wrong passphrase?")
+	}
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// Passphrase returns the passphrase of the secrets file from the environment or the terminal
+func Passphrase() (string, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	passphrase := PromptSecret("Passphrase of the secrets file")
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase is required, set %s or run in a terminal", PassphraseEnv)
+	}
+	return passphrase, nil
+}
+
+// ReadSecrets reads and decrypts the secrets file
+func ReadSecrets(filename string, passphrase string) (map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := DecryptSecrets(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return secrets, nil
+}
+
+// WriteSecrets encrypts and writes the secrets file
+func WriteSecrets(filename string, secrets map[string]string, passphrase string) error {
+	data, err := EncryptSecrets(secrets, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0600)
+}
+
+// SecretKey returns the name of the token of the zone in the secrets file
+func SecretKey(zone string) string {
+	if zone == "" || zone == DefaultProfile {
+		return "cf_token"
+	}
+	return "zones." + zone + ".cf_token"
+}
+
+// openedSecrets keeps the decrypted secrets files to ask the passphrase once
+var openedSecrets = make(map[string]map[string]string)
+
+// SecretToken returns the token of the zone from the secrets file,
+// a zone without its own token uses the top level one
+func SecretToken(filename string, zone string) (string, error) {
+	secrets, ok := openedSecrets[filename]
+	if !ok {
+		passphrase, err := Passphrase()
+		if err != nil {
+			return "", err
+		}
+		secrets, err = ReadSecrets(filename, passphrase)
+		if err != nil {
+			return "", err
+		}
+		openedSecrets[filename] = secrets
+	}
+	for _, key := range []string{SecretKey(zone), SecretKey("")} {
+		if token, ok := secrets[key]; ok && token != "" {
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf("%s has no %s", filename, SecretKey(zone))
+}
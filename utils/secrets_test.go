@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretsRoundTrip(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "secrets.json")
+	secrets := map[string]string{
+		SecretKey(""):      "top-level-token",
+		SecretKey("other"): "zone-token",
+	}
+	if err := WriteSecrets(filename, secrets, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadSecrets(filename, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(secrets) {
+		t.Fatalf("ReadSecrets() = %v, want %v", got, secrets)
+	}
+	for key, value := range secrets {
+		if got[key] != value {
+			t.Errorf("ReadSecrets()[%q] = %q, want %q", key, got[key], value)
+		}
+	}
+}
+
+func TestSecretsWrongPassphrase(t *testing.T) {
+	data, err := EncryptSecrets(map[string]string{"cf_token": "secret"}, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets, err := DecryptSecrets(data, "wrong horse"); err == nil {
+		t.Errorf("DecryptSecrets() with a wrong passphrase = %v, want an error", secrets)
+	}
+	if _, err := EncryptSecrets(map[string]string{"cf_token": "secret"}, ""); err == nil {
+		t.Error("EncryptSecrets() with an empty passphrase succeeded")
+	}
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// knownSecrets are the secrets scrubbed from the output
+var knownSecrets []string
+
+// AddSecret registers a secret which must never be printed
+func AddSecret(secret string) {
+	if len(secret) >= 4 && !TypeContains(knownSecrets, secret) {
+		knownSecrets = append(knownSecrets, secret)
+	}
+}
+
+// Scrub redacts the registered secrets found in the text
+func Scrub(text string) string {
+	for _, secret := range knownSecrets {
+		text = strings.ReplaceAll(text, secret, Redact(secret))
+	}
+	return text
+}
+
+// ScrubError redacts the registered secrets found in the error
+func ScrubError(err error) error {
+	if err == nil {
+		return nil
+	}
+	scrubbed := Scrub(err.Error())
+	if scrubbed == err.Error() {
+		return err
+	}
+	return fmt.Errorf("%s", scrubbed)
+}
+
+// ReadTokenFile reads the token from the file, surrounding whitespace is ignored
+func ReadTokenFile(filename string) (string, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		fmt.Fprintf(os.Stderr, "WARN - token file %s is readable by other users, run chmod 600 %s\n", filename, filename)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", filename)
+	}
+	return token, nil
+}
+
+// RunTokenCommand runs the command with the shell and returns the first line it prints
+func RunTokenCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	// the first line is the token like `pass show` prints it
+	token := strings.TrimSpace(strings.SplitN(stdout.String(), "\n", 2)[0])
+	AddSecret(token)
+	if err != nil {
+		return "", fmt.Errorf("token command %q failed: %v: %s", command, err, Scrub(strings.TrimSpace(stderr.String())))
+	}
+	if token == "" {
+		return "", fmt.Errorf("token command %q printed nothing", command)
+	}
+	return token, nil
+}
+
+// ResolveToken returns the token of the zone from the first source set:
+// cf_token, cf_token_file, token_command or the secrets file
+func ResolveToken(zone models.Zone, secretsFile string) (string, error) {
+	var token string
+	var err error
+	switch {
+	case zone.CFToken != "":
+		token = zone.CFToken
+	case zone.CFTokenFile != "":
+		token, err = ReadTokenFile(zone.CFTokenFile)
+	case zone.TokenCommand != "":
+		token, err = RunTokenCommand(zone.TokenCommand)
+	case secretsFile != "":
+		token, err = SecretToken(secretsFile, zone.Name)
+	default:
+		err = fmt.Errorf("no token is configured, set cf_token, cf_token_file, token_command or secrets_file")
+	}
+	if err != nil {
+		return "", ScrubError(err)
+	}
+	AddSecret(token)
+	return token, nil
+}
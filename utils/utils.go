@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -8,11 +9,11 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/mrinjamul/mrinjamulcf-cli/models"
+	"golang.org/x/term"
 )
 
 // HomeDir returns the home directory of the current user
@@ -27,7 +28,8 @@ func HomeDir() string {
 // GenTips generates random tips
 func GenTips() string {
 	tips := []string{
-		// "Use `mrinjamulcf-cli config --gen` to generate config file",
+		"Use `mrinjamulcf-cli config init` to generate config file",
+		"Use `mrinjamulcf-cli config show` to see where every setting comes from",
 		"Use `mrinjamulcf-cli sync --dry-run` to see what will be synced",
 		"Use `mrinjamulcf-cli sync` to sync your records",
 		"Use `mrinjamulcf-cli sync --domain [url]` to specify the root domain",
@@ -35,110 +37,27 @@ func GenTips() string {
 		"Use `mrinjamulcf-cli fmt --check` to check records file",
 		"Use `mrinjamulcf-cli fmt` to format records file",
 		"Use `mrinjamulcf-cli fmt --domain [url]` to specify the root domain",
-		// "Use `mrinjamulcf-cli fmt --dry-run` to see what will be formatted",
+		"Use `mrinjamulcf-cli fmt --dry-run` to see what will be formatted",
+		"Use `mrinjamulcf-cli fmt --diff` to see how the records file will change",
 	}
 	rand.Seed(time.Now().UnixNano())
 	return tips[rand.Intn(len(tips))]
 }
 
-// GenerateConfig generates the config file
-func GenerateConfig(filename string) error {
-	recordFile := HomeDir() + "/.mrinjamulcli_dns_records.json"
-	if _, err := os.Stat("records.json"); err == nil {
-		// get current path
-		path, _ := os.Getwd()
-		recordFile = path + "/" + "records.json"
-	}
-	config := models.Config{
-		DomainName: "",
-		RecordFile: recordFile,
-		CFToken:    "",
-		ZoneID:     "",
-		RecordType: []string{"A", "CNAME"},
-	}
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return err
-	}
-	err = ioutil.WriteFile(filename, data, 0644)
-	if err != nil {
-		return err
-	}
-	if _, err := os.Stat(recordFile); os.IsNotExist(err) {
-		records := []models.Records{
-			{
-				Description: "This is a sample record",
-				Repo:        "",
-				Owner: models.Owner{
-					Username: "username",
-					Email:    "username@domain.com",
-				},
-				Record: models.Record{
-					Type:      "A",
-					Name:      "*.dev",
-					Content:   "127.0.0.1",
-					Proxiable: true,
-					Proxied:   true,
-					TTL:       1,
-				},
-			},
-		}
-		data, err := json.MarshalIndent(records, "", "  ")
-		if err != nil {
-			return err
-		}
-		err = ioutil.WriteFile(recordFile, data, 0644)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-// ParseConfig parses the config file
-func ParseConfig(filename string) (models.Config, error) {
-	var config models.Config
-	data, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return config, err
-	}
-	err = json.Unmarshal(data, &config)
-	if err != nil {
-		return config, err
-	}
-	return config, nil
-}
-
-// GetConfig returns the config variables
-func GetConfig(filename string) (DomainName, RecordFile, RestrictedFile, CFToken, ZoneID string, RecordType []string) {
-	// check if config file exists
-	if filename == "" {
-		filename = HomeDir() + "/.mrinjamulcli.json"
-		if _, err := os.Stat(filename); os.IsNotExist(err) {
-			// fmt.Println("Config file not found. Please run `mrinjamulcf-cli config --gen` to generate config file")
-			// GenerateConfig(filename)
-			return "", "", "", "", "", []string{}
-		}
-	}
-	config, err := ParseConfig(filename)
-	if err != nil {
-		fmt.Println("ERROR - fail to parse config file")
-		GenerateConfig(filename)
-		os.Exit(1)
-	}
-	return config.DomainName, config.RecordFile, config.RestrictedFile, config.CFToken, config.ZoneID, config.RecordType
-}
-
 // GetRecords parse records from records file
 func GetRecords(filename string) ([]models.Records, error) {
-	var records []models.Records
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return []models.Records{}, err
 	}
-	err = json.Unmarshal(data, &records)
-	if err != nil {
+	return ParseRecords(data)
+}
 
+// ParseRecords parses the content of a records file
+func ParseRecords(data []byte) ([]models.Records, error) {
+	var records []models.Records
+	err := json.Unmarshal(data, &records)
+	if err != nil {
 		return []models.Records{}, err
 	}
 	return records, nil
@@ -218,7 +137,7 @@ func CFFetch(base string, endpoint string, token string) (models.CFResponse, err
 	// Create a new request using http
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		fmt.Println(err)
+		return models.CFResponse{}, err
 	}
 	// add authorization header to the req
 	req.Header.Add("Authorization", bearer)
@@ -226,20 +145,20 @@ func CFFetch(base string, endpoint string, token string) (models.CFResponse, err
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Println("Error on response.\nERROR -", err)
+		return models.CFResponse{}, ScrubError(err)
 	}
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println("Error while reading the response bytes:", err)
+		fmt.Fprintln(os.Stderr, "Error while reading the response bytes:", err)
 	}
 	err = json.Unmarshal(body, &result)
 	if err != nil {
-		fmt.Println(body)
-		fmt.Println("Error while parsing the response bytes:", err)
+		fmt.Fprintln(os.Stderr, body)
+		fmt.Fprintln(os.Stderr, "Error while parsing the response bytes:", err)
 	}
 	if len(result.Errors) > 0 {
-		return models.CFResponse{}, fmt.Errorf("%s", result.Errors[0].Message)
+		return models.CFResponse{}, fmt.Errorf("%s", Scrub(result.Errors[0].Message))
 	}
 	return result, nil
 }
@@ -257,7 +176,7 @@ func CFPost(method string, base string, endpoint string, postBody []byte, token
 	// Create a new request using http
 	req, err := http.NewRequest(method, url, responseBody)
 	if err != nil {
-		fmt.Println(err)
+		return models.PostResponse{}, err
 	}
 	// add authorization header to the req
 	req.Header.Add("Authorization", bearer)
@@ -265,19 +184,19 @@ func CFPost(method string, base string, endpoint string, postBody []byte, token
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Println("Error on response.\nERROR -", err)
+		return models.PostResponse{}, ScrubError(err)
 	}
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println("Error while reading the response bytes:", err)
+		fmt.Fprintln(os.Stderr, "Error while reading the response bytes:", err)
 	}
 	err = json.Unmarshal(body, &result)
 	if err != nil {
-		fmt.Println("Error while parsing the response bytes:", err)
+		fmt.Fprintln(os.Stderr, "Error while parsing the response bytes:", err)
 	}
 	if len(result.Errors) > 0 {
-		return models.PostResponse{}, fmt.Errorf("%s", result.Errors[0].Message)
+		return models.PostResponse{}, fmt.Errorf("%s", Scrub(result.Errors[0].Message))
 	}
 	return result, nil
 }
@@ -291,7 +210,7 @@ func CFDelete(base string, endpoint string, token string) (models.DelResponse, e
 	// Create a new request using http
 	req, err := http.NewRequest("DELETE", url, nil)
 	if err != nil {
-		fmt.Println(err)
+		return models.DelResponse{}, err
 	}
 	// add authorization header to the req
 	req.Header.Add("Authorization", bearer)
@@ -299,19 +218,19 @@ func CFDelete(base string, endpoint string, token string) (models.DelResponse, e
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Println("Error on response.\nERROR -", err)
+		return models.DelResponse{}, ScrubError(err)
 	}
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println("Error while reading the response bytes:", err)
+		fmt.Fprintln(os.Stderr, "Error while reading the response bytes:", err)
 	}
 	err = json.Unmarshal(body, &result)
 	if err != nil {
-		fmt.Println("Error while parsing the response bytes:", err)
+		fmt.Fprintln(os.Stderr, "Error while parsing the response bytes:", err)
 	}
 	if len(result.Errors) > 0 {
-		return models.DelResponse{}, fmt.Errorf("%s", result.Errors[0].Message)
+		return models.DelResponse{}, fmt.Errorf("%s", Scrub(result.Errors[0].Message))
 	}
 	return result, nil
 }
@@ -358,52 +277,82 @@ func RandomNumber() string {
 	return fmt.Sprintf("%d", rand.Intn(999))
 }
 
-// RemoveRestrictedSubdomains removes restricted subdomains from the list in restricted.json
-func RemoveRestrictedSubdomains(filename string, localRecords []models.Record) (localNonRestrictedRecords []models.Record, localRestrictedRecords []models.Record) {
-	restrictedRecords := ReadRestrictedRecords(filename)
-	for _, record := range localRecords {
-		if !IsRestricted(record.Name, restrictedRecords) {
-			localNonRestrictedRecords = append(localNonRestrictedRecords, record)
-		} else {
-			localRestrictedRecords = append(localRestrictedRecords, record)
-		}
+// stdin is shared by the prompts so buffered input is not lost between them
+var stdin = bufio.NewReader(os.Stdin)
+
+// AssumeYes and AssumeNo answers every prompt without asking
+var (
+	AssumeYes bool
+	AssumeNo  bool
+)
+
+// IsTerminal checks if the file is a terminal, pipes, regular files and the null device are not
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
 	}
-	return localNonRestrictedRecords, localRestrictedRecords
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(info, null) {
+		return false
+	}
+	return true
 }
 
-// IsRestricted checks if the record is restricted
-func IsRestricted(name string, restrictedRecords []string) bool {
-	for _, record := range restrictedRecords {
-		// check using regular expression
-		if regexp.MustCompile(record).MatchString(name) {
-			return true
-		}
-	}
-	return false
+// Prompt asks for a value, the default is used for an empty answer
+// or when the prompt cannot wait for input
+func Prompt(message string, defaultValue string) string {
+	if defaultValue != "" {
+		fmt.Printf("%s [%s]: ", message, defaultValue)
+	} else {
+		fmt.Printf("%s: ", message)
+	}
+	if AssumeYes || AssumeNo || !IsTerminal(os.Stdin) {
+		fmt.Println(defaultValue)
+		return defaultValue
+	}
+	response, _ := stdin.ReadString('\n')
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return defaultValue
+	}
+	return response
 }
 
-// ReadRestrictedRecords read restricted records from restricted.json and store in a array
-func ReadRestrictedRecords(filename string) []string {
-	type Restricted struct {
-		RestrictedSubdomain []string `json:"restricted_subdomain"`
-	}
-	restrictedRecords := Restricted{}
-	file, err := ioutil.ReadFile(filename)
+// PromptSecret asks for a value without echoing it, the answer is empty
+// when the prompt cannot wait for input
+func PromptSecret(message string) string {
+	fmt.Printf("%s: ", message)
+	if AssumeYes || AssumeNo || !IsTerminal(os.Stdin) {
+		fmt.Println()
+		return ""
+	}
+	response, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
 	if err != nil {
-		fmt.Println(err)
+		return ""
 	}
-	err = json.Unmarshal(file, &restrictedRecords)
-	if err != nil {
-		fmt.Println(err)
-	}
-	return restrictedRecords.RestrictedSubdomain
+	return strings.TrimSpace(string(response))
 }
 
-// ConfirmPrompt will prompt to user for yes or no
+// ConfirmPrompt will prompt to user for yes or no.
+// It does not wait for input when --yes or --no is set or stdin is not a terminal.
 func ConfirmPrompt(message string) bool {
 	var response string
 	fmt.Print(message + " (yes/no) :")
-	fmt.Scanln(&response)
+	switch {
+	case AssumeYes:
+		fmt.Println("yes")
+		return true
+	case AssumeNo:
+		fmt.Println("no")
+		return false
+	case !IsTerminal(os.Stdin):
+		fmt.Println("no")
+		fmt.Println("WARN - stdin is not a terminal, answering no (use --yes to confirm)")
+		return false
+	}
+	response, _ = stdin.ReadString('\n')
+	response = strings.TrimSpace(response)
 
 	switch strings.ToLower(response) {
 	case "y", "yes":
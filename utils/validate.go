@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// Issue levels reported by the record checks
+const (
+	LevelError   = "ERROR"
+	LevelWarning = "WARN"
+)
+
+// Rules of the record checks, they tell the issues apart when the message
+// changes e.g. with the count of a quota
+const (
+	RuleEmpty         = "empty"
+	RuleProxied       = "proxied"
+	RuleName          = "name"
+	RuleRestricted    = "restricted"
+	RuleConfusable    = "confusable"
+	RuleLookalike     = "lookalike"
+	RulePrivateIP     = "private-ip"
+	RuleOwnerUsername = "owner-username"
+	RuleOwnerEmail    = "owner-email"
+	RuleAllowedTypes  = "allowed-types"
+	RuleQuota         = "quota"
+	RuleParentOwner   = "parent-owner"
+	RuleSharedName    = "shared-name"
+	RuleVerifyOwner   = "verify-owner"
+	RuleVerifyRepo    = "verify-repo"
+	RuleVerifyRepoOf  = "verify-repo-owner"
+	RuleVerifyPages   = "verify-pages"
+)
+
+// Issue is a problem found while checking a record,
+// Rule is the check which found it when it is a record check
+type Issue struct {
+	Level   string
+	Name    string
+	Message string
+	Rule    string
+}
+
+// String returns the issue in the log format used by the CLI
+func (i Issue) String() string {
+	return fmt.Sprintf("%s - %s: %s", i.Level, i.Name, i.Message)
+}
+
+// HasErrors checks if any of the issues is an error
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Level == LevelError {
+			return true
+		}
+	}
+	return false
+}
+
+// reservedNetworks are the address ranges which are not routable on the public internet
+var reservedNetworks = []struct {
+	cidr  string
+	label string
+}{
+	{"0.0.0.0/8", "\"this\" network"},
+	{"10.0.0.0/8", "private network (RFC 1918)"},
+	{"100.64.0.0/10", "carrier-grade NAT (RFC 6598)"},
+	{"127.0.0.0/8", "loopback"},
+	{"169.254.0.0/16", "link-local"},
+	{"172.16.0.0/12", "private network (RFC 1918)"},
+	{"192.0.0.0/24", "IETF protocol assignment"},
+	{"192.0.2.0/24", "documentation (TEST-NET-1)"},
+	{"192.168.0.0/16", "private network (RFC 1918)"},
+	{"198.18.0.0/15", "benchmarking"},
+	{"198.51.100.0/24", "documentation (TEST-NET-2)"},
+	{"203.0.113.0/24", "documentation (TEST-NET-3)"},
+	{"224.0.0.0/4", "multicast"},
+	{"240.0.0.0/4", "reserved"},
+	{"::/128", "unspecified"},
+	{"::1/128", "loopback"},
+	{"100::/64", "discard-only"},
+	{"2001:db8::/32", "documentation"},
+	{"fc00::/7", "unique local"},
+	{"fe80::/10", "link-local"},
+	{"ff00::/8", "multicast"},
+}
+
+// ReservedIPRange returns the name of the reserved range the address belongs to
+func ReservedIPRange(address string) (string, bool) {
+	ip := net.ParseIP(strings.TrimSpace(address))
+	if ip == nil {
+		return "", false
+	}
+	for _, network := range reservedNetworks {
+		_, ipnet, err := net.ParseCIDR(network.cidr)
+		if err != nil {
+			continue
+		}
+		if ipnet.Contains(ip) {
+			return network.label, true
+		}
+	}
+	return "", false
+}
+
+// NameAllowed checks if the name matches any of the patterns in the allowlist
+func NameAllowed(name string, allowlist []string) bool {
+	for _, pattern := range allowlist {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPrivateIPs reports A/AAAA records which point at private, loopback or reserved addresses.
+// policy is one of "error", "warn" or "off".
+func CheckPrivateIPs(records []models.Records, policy string, allowlist []string) []Issue {
+	var issues []Issue
+	level := LevelError
+	switch strings.ToLower(policy) {
+	case "off":
+		return issues
+	case "warn", "warning":
+		level = LevelWarning
+	}
+	for _, entry := range records {
+		record := entry.Record
+		if record.Type != "A" && record.Type != "AAAA" {
+			continue
+		}
+		label, reserved := ReservedIPRange(record.Content)
+		if !reserved || NameAllowed(record.Name, allowlist) {
+			continue
+		}
+		issues = append(issues, Issue{
+			Level:   level,
+			Name:    record.Name,
+			Message: fmt.Sprintf("%s points at %s address %s", record.Type, label, record.Content),
+			Rule:    RulePrivateIP,
+		})
+	}
+	return issues
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+func TestReservedIPRange(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"10.1.2.3", "private network (RFC 1918)"},
+		{"172.16.0.1", "private network (RFC 1918)"},
+		{"172.31.255.255", "private network (RFC 1918)"},
+		{"192.168.1.10", "private network (RFC 1918)"},
+		{"100.64.0.1", "carrier-grade NAT (RFC 6598)"},
+		{"100.127.255.254", "carrier-grade NAT (RFC 6598)"},
+		{"127.0.0.1", "loopback"},
+		{"169.254.169.254", "link-local"},
+		{"::1", "loopback"},
+		{"fe80::1", "link-local"},
+		{"fd12:3456::1", "unique local"},
+		{"fc00::1", "unique local"},
+		{"2001:db8::1", "documentation"},
+		{"::ffff:10.0.0.1", "private network (RFC 1918)"},
+		{"::ffff:127.0.0.1", "loopback"},
+		{"::ffff:100.64.1.1", "carrier-grade NAT (RFC 6598)"},
+		{" 192.168.1.10 ", "private network (RFC 1918)"},
+		// public addresses around the ranges
+		{"8.8.8.8", ""},
+		{"172.15.255.255", ""},
+		{"172.32.0.0", ""},
+		{"100.63.255.255", ""},
+		{"100.128.0.0", ""},
+		{"192.169.0.1", ""},
+		{"2606:4700:4700::1111", ""},
+		{"::ffff:8.8.8.8", ""},
+		{"not an address", ""},
+		{"example.com", ""},
+	}
+	for _, tt := range tests {
+		label, reserved := ReservedIPRange(tt.address)
+		if reserved != (tt.want != "") || label != tt.want {
+			t.Errorf("ReservedIPRange(%q) = %q, %t, want %q", tt.address, label, reserved, tt.want)
+		}
+	}
+}
+
+func TestCheckPrivateIPs(t *testing.T) {
+	records := []models.Records{
+		{Record: models.Record{Type: "A", Name: "nas", Content: "192.168.1.10"}},
+		{Record: models.Record{Type: "AAAA", Name: "home", Content: "fd00::10"}},
+		{Record: models.Record{Type: "A", Name: "lab.internal", Content: "10.0.0.1"}},
+		{Record: models.Record{Type: "A", Name: "www", Content: "8.8.8.8"}},
+		{Record: models.Record{Type: "CNAME", Name: "blog", Content: "10.0.0.1"}},
+		{Record: models.Record{Type: "TXT", Name: "txt", Content: "127.0.0.1"}},
+	}
+	tests := []struct {
+		policy    string
+		allowlist []string
+		level     string
+		names     []string
+	}{
+		{"error", nil, LevelError, []string{"nas", "home", "lab.internal"}},
+		{"", nil, LevelError, []string{"nas", "home", "lab.internal"}},
+		{"warn", nil, LevelWarning, []string{"nas", "home", "lab.internal"}},
+		{"WARNING", nil, LevelWarning, []string{"nas", "home", "lab.internal"}},
+		{"off", nil, "", nil},
+		{"error", []string{"NAS", "*.internal"}, LevelError, []string{"home"}},
+		{"warn", []string{"h?me"}, LevelWarning, []string{"nas", "lab.internal"}},
+	}
+	for _, tt := range tests {
+		issues := CheckPrivateIPs(records, tt.policy, tt.allowlist)
+		if len(issues) != len(tt.names) {
+			t.Errorf("CheckPrivateIPs(%q, %v) = %v, want %v", tt.policy, tt.allowlist, issues, tt.names)
+			continue
+		}
+		for i, issue := range issues {
+			if issue.Name != tt.names[i] || issue.Level != tt.level || issue.Rule != RulePrivateIP {
+				t.Errorf("CheckPrivateIPs(%q, %v)[%d] = %v, want %s on %s", tt.policy, tt.allowlist, i, issue, tt.level, tt.names[i])
+			}
+		}
+	}
+}
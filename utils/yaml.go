@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MarshalYAML encodes structs, maps, slices and scalars as YAML,
+// the field names and omitempty are taken from the json tags
+func MarshalYAML(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeYAML(&b, reflect.ValueOf(v), 0, false); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// yamlField is a struct field with its key
+type yamlField struct {
+	key   string
+	value reflect.Value
+}
+
+// yamlFields returns the fields of the struct which are encoded
+func yamlFields(v reflect.Value) []yamlField {
+	var fields []yamlField
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		key := field.Name
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		if tag[0] != "" {
+			key = tag[0]
+		}
+		if len(tag) > 1 && tag[1] == "omitempty" && v.Field(i).IsZero() {
+			continue
+		}
+		fields = append(fields, yamlField{key, v.Field(i)})
+	}
+	return fields
+}
+
+// yamlEntries returns the entries of a struct or a map sorted by key
+func yamlEntries(v reflect.Value) []yamlField {
+	if v.Kind() == reflect.Struct {
+		return yamlFields(v)
+	}
+	var entries []yamlField
+	for _, key := range v.MapKeys() {
+		entries = append(entries, yamlField{fmt.Sprint(key.Interface()), v.MapIndex(key)})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return entries
+}
+
+// writeYAML writes the value, inline is set when the value follows `- ` or `key: `
+func writeYAML(b *bytes.Buffer, v reflect.Value, indent int, inline bool) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			b.WriteString("null\n")
+			return nil
+		}
+		v = v.Elem()
+	}
+	pad := strings.Repeat("  ", indent)
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map:
+		entries := yamlEntries(v)
+		if len(entries) == 0 {
+			b.WriteString("{}\n")
+			return nil
+		}
+		for i, entry := range entries {
+			if i > 0 || !inline {
+				b.WriteString(pad)
+			}
+			b.WriteString(yamlScalar(entry.key) + ":")
+			if yamlComposite(entry.value) {
+				b.WriteString("\n")
+				if err := writeYAML(b, entry.value, indent+1, false); err != nil {
+					return err
+				}
+				continue
+			}
+			b.WriteString(" ")
+			if err := writeYAML(b, entry.value, indent+1, true); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			b.WriteString("[]\n")
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 || !inline {
+				b.WriteString(pad)
+			}
+			b.WriteString("- ")
+			if err := writeYAML(b, v.Index(i), indent+1, true); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		b.WriteString(yamlScalar(v.String()) + "\n")
+	case reflect.Bool:
+		b.WriteString(strconv.FormatBool(v.Bool()) + "\n")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(strconv.FormatInt(v.Int(), 10) + "\n")
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10) + "\n")
+	case reflect.Float32, reflect.Float64:
+		b.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64) + "\n")
+	default:
+		return fmt.Errorf("cannot encode %s as yaml", v.Kind())
+	}
+	return nil
+}
+
+// yamlComposite checks if the value is a non empty struct, map or list written on its own lines
+func yamlComposite(v reflect.Value) bool {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return len(yamlFields(v)) > 0
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return v.Len() > 0
+	}
+	return false
+}
+
+// yamlTimestamp matches the strings YAML reads as a date
+var yamlTimestamp = regexp.MustCompile(`^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}`)
+
+// yamlScalar quotes the string when it would not be read back as the same string
+func yamlScalar(s string) string {
+	plain := s != "" && strings.TrimSpace(s) == s
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		plain = false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil || yamlTimestamp.MatchString(s) {
+		plain = false
+	}
+	if plain && strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		plain = false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.ContainsAny(s, "\n\t") {
+		plain = false
+	}
+	if plain {
+		return s
+	}
+	// a JSON string is a valid double quoted YAML scalar
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
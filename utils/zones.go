@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mrinjamul/mrinjamulcf-cli/models"
+)
+
+// DefaultProfile is the name of the zone made from the top level config
+const DefaultProfile = "default"
+
+// ZoneSelector selects the zones to work on
+type ZoneSelector struct {
+	// Profile is the name of the zone
+	Profile string
+	// Zone is the domain name or the zone id
+	Zone string
+	// All selects every zone
+	All bool
+}
+
+// ZoneRecordFile returns the default records file of a zone
+func ZoneRecordFile(domain string) string {
+	return "records." + domain + ".json"
+}
+
+// inheritZone fills the fields of the zone which are not set from the top level config
+func inheritZone(zone models.Zone, config models.Config) models.Zone {
+	// the token is inherited with all its sources
+	if zone.CFToken == "" && zone.CFTokenFile == "" && zone.TokenCommand == "" {
+		zone.CFToken = config.CFToken
+		zone.CFTokenFile = config.CFTokenFile
+		zone.TokenCommand = config.TokenCommand
+	}
+	if zone.DomainName == "" {
+		zone.DomainName = config.DomainName
+	}
+	if zone.RecordFile == "" {
+		zone.RecordFile = ZoneRecordFile(zone.DomainName)
+	}
+	if zone.RestrictedFile == "" {
+		zone.RestrictedFile = config.RestrictedFile
+	}
+	if len(zone.RecordType) == 0 {
+		zone.RecordType = config.RecordType
+	}
+	return zone
+}
+
+// ResolveZones returns the zones selected from the config.
+// Without zones in the config the top level config is the only zone.
+func ResolveZones(config models.Config, selector ZoneSelector) ([]models.Zone, error) {
+	if len(config.Zones) == 0 {
+		zone := models.Zone{
+			Name:           DefaultProfile,
+			CFToken:        config.CFToken,
+			CFTokenFile:    config.CFTokenFile,
+			TokenCommand:   config.TokenCommand,
+			ZoneID:         config.ZoneID,
+			DomainName:     config.DomainName,
+			RecordFile:     config.RecordFile,
+			RestrictedFile: config.RestrictedFile,
+			RecordType:     config.RecordType,
+		}
+		if selector.Profile != "" && selector.Profile != DefaultProfile {
+			return nil, fmt.Errorf("profile %q not found, no zones are configured", selector.Profile)
+		}
+		if selector.Zone != "" && !strings.EqualFold(selector.Zone, zone.DomainName) && selector.Zone != zone.ZoneID {
+			return nil, fmt.Errorf("zone %q not found, no zones are configured", selector.Zone)
+		}
+		return []models.Zone{zone}, nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, zone := range config.Zones {
+		if zone.Name == "" {
+			return nil, fmt.Errorf("every zone needs a name")
+		}
+		if seen[zone.Name] {
+			return nil, fmt.Errorf("zone name %q is used twice", zone.Name)
+		}
+		seen[zone.Name] = true
+		names = append(names, zone.Name)
+	}
+
+	var zones []models.Zone
+	for _, zone := range config.Zones {
+		zone = inheritZone(zone, config)
+		switch {
+		case selector.All:
+		case selector.Profile != "":
+			if zone.Name != selector.Profile {
+				continue
+			}
+		case selector.Zone != "":
+			if !strings.EqualFold(zone.DomainName, selector.Zone) && zone.ZoneID != selector.Zone {
+				continue
+			}
+		case config.Profile != "":
+			if zone.Name != config.Profile {
+				continue
+			}
+		default:
+			// the first zone is used when nothing is selected
+			if len(zones) > 0 {
+				continue
+			}
+		}
+		zones = append(zones, zone)
+	}
+	if len(zones) == 0 {
+		selected := selector.Profile
+		if selected == "" {
+			selected = selector.Zone
+		}
+		if selected == "" {
+			selected = config.Profile
+		}
+		return nil, fmt.Errorf("zone %q not found (available: %s)", selected, strings.Join(names, ", "))
+	}
+	return zones, nil
+}